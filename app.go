@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // static const values
@@ -21,6 +23,9 @@ var (
 
 	// Maxium middlewares
 	MAX_MIDDLEWARE = 32
+
+	// Maxium time to wait active requests when shutdown by signal
+	SHUTDOWN_TIMEOUT = 30 * time.Second
 )
 
 //
@@ -28,15 +33,47 @@ var (
 // store global objects, such as middleware
 //
 type Application struct {
-	mws  []Middleware // all middlewares
-	pool sync.Pool    // cache Context
+	active int64        // requests in handling, keep first for atomic align
+	opts   *Options     // options
+	mws    []Middleware // all middlewares
+	pool   sync.Pool    // cache Context
+
+	// serving
+	mu       sync.Mutex
+	srvs     []*http.Server // running servers
+	lns      []*appListener // listeners for restart
+	hooks    []func()       // run after shutdown
+	closing  bool           // shutdown started
+	downOnce sync.Once      // shutdown once
+	done     chan struct{}  // closed when shutdown finished
+
+	// tls
+	certs    certStore // certs for SNI
+	redirect string    // plain http addr to redirect to https
 }
 
-// Create empty application without any middleware
-func NewApp() *Application {
+// Create empty application without any middleware,
+// opts is optional, DefaultOptions if not given, such as:
+//
+//	o := uweb.DefaultOptions()
+//	o.LogTag = "[admin]"
+//	admin := uweb.NewApp(o)
+//
+func NewApp(opts ...*Options) *Application {
+	// opts
+	var o *Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	o = o.check()
+
 	// app
 	app := &Application{
-		mws: make([]Middleware, 0),
+		opts:  o,
+		mws:   make([]Middleware, 0),
+		done:  make(chan struct{}),
+		certs: certStore{opts: o},
 	}
 	// pool
 	app.pool.New = func() interface{} {
@@ -46,29 +83,52 @@ func NewApp() *Application {
 	return app
 }
 
+// Get options
+func (a *Application) Options() *Options {
+	return a.opts
+}
+
 // Add one middleware
 func (a *Application) Use(m Middleware) {
-	if len(a.mws) > MAX_MIDDLEWARE {
+	if len(a.mws) > a.opts.MaxMiddleware {
 		panic("too many middlewares")
 	}
+	if b, ok := m.(appBinder); ok {
+		b.bind(a)
+	}
 	a.mws = append(a.mws, m)
 }
 
+// Middleware bound to app when used, such as to read
+// its options before serving
+type appBinder interface {
+	bind(a *Application)
+}
+
 // Listen and start serve
 func (a *Application) Listen(addr string) error {
-	if DEBUG {
-		log.Println(LOG_TAG, "Application: listen at", addr)
+	if a.opts.Debug {
+		log.Println(a.opts.LogTag, "Application: listen at", addr)
+	}
+	ln, err := a.listen("tcp", addr)
+	if err != nil {
+		return err
 	}
-	return http.ListenAndServe(addr, a)
+	return a.serve(&http.Server{Addr: addr, Handler: a}, ln)
 }
 
 // Handle all http request
 // @impl http.Handler
 func (a *Application) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// count active requests for graceful shutdown
+	atomic.AddInt64(&a.active, 1)
+	defer atomic.AddInt64(&a.active, -1)
+
 	// get c
 	c := a.pool.Get().(*Context)
 
 	// run all middlewares and end the response
+	c.mws = a.mws
 	c.Req = NewRequest(req)
 	c.Res = NewResponse(w)
 	if c.Next() != NEXT_ABORT {
@@ -0,0 +1,394 @@
+package uweb
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// Maxium memory for multipart form, others are stored in temp files
+	MAX_MULTIPART_MEMORY int64 = 32 << 20
+)
+
+var (
+	ErrBindTarget = errors.New("Bind: target should be pointer to struct")
+	ErrBindType   = errors.New("Bind: unsupported content type")
+)
+
+//
+// Field error of binding or validating
+//
+type FieldError struct {
+	Field string // name in form or json, such as "addr.city"
+	Value string // raw value
+	Rule  string // validate rule, empty if binding failed
+	Param string // param of rule
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+//
+// Field errors, in order of fields
+//
+type FieldErrors []*FieldError
+
+func (es FieldErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Get error of field, nil if not found
+func (es FieldErrors) Get(field string) *FieldError {
+	for _, e := range es {
+		if e.Field == field {
+			return e
+		}
+	}
+	return nil
+}
+
+// Bind request data to v, which is pointer to struct.
+// Decoder is chosen by Content-Type, json, xml, or form
+// with query for others. Form fields are named by "form"
+// tag, then "json" tag, then field name, and nested as
+// "addr.city". Tag "default" sets value if it's missing,
+// and "format" is layout of time, RFC3339 by default.
+// Conversion errors are returned as FieldErrors, and then
+// v is validated by Validate.
+func (req *Request) Bind(v interface{}) error {
+	if err := req.bind(v); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// Bind by content type
+func (req *Request) bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrBindTarget
+	}
+	if err := setDefaults(rv.Elem()); err != nil {
+		return err
+	}
+
+	ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case ct == "application/json" || strings.HasSuffix(ct, "+json"):
+		return bindJson(req, v)
+	case ct == "application/xml" || ct == "text/xml" || strings.HasSuffix(ct, "+xml"):
+		return bindXml(req, v)
+	case ct == "multipart/form-data":
+		if err := req.ParseMultipartForm(MAX_MULTIPART_MEMORY); err != nil {
+			return err
+		}
+		var errs FieldErrors
+		bindForm(rv.Elem(), "", req.Form, req.MultipartForm.File, &errs)
+		return errs.orNil()
+	case ct == "", ct == "application/x-www-form-urlencoded", req.Method == "GET", req.Method == "HEAD", req.Method == "DELETE":
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		var errs FieldErrors
+		bindForm(rv.Elem(), "", req.Form, nil, &errs)
+		return errs.orNil()
+	}
+	return ErrBindType
+}
+
+// nil if no error, as nil slice in error is not nil
+func (es FieldErrors) orNil() error {
+	if len(es) == 0 {
+		return nil
+	}
+	return es
+}
+
+// Decode json body, type errors are returned as FieldErrors
+func bindJson(req *Request, v interface{}) error {
+	if req.Body == nil {
+		return nil
+	}
+	err := json.NewDecoder(req.Body).Decode(v)
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.UnmarshalTypeError:
+		return FieldErrors{{
+			Field: e.Field,
+			Err:   fmt.Errorf("%s should be %s", e.Value, e.Type),
+		}}
+	}
+	if err == io.EOF {
+		return nil // empty body
+	}
+	return fmt.Errorf("Bind: invalid json, %v", err)
+}
+
+// Decode xml body
+func bindXml(req *Request, v interface{}) error {
+	if req.Body == nil {
+		return nil
+	}
+	if err := xml.NewDecoder(req.Body).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("Bind: invalid xml, %v", err)
+	}
+	return nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+)
+
+// Bind form values and files to struct fields
+func bindForm(v reflect.Value, prefix string, form url.Values, files map[string][]*multipart.FileHeader, errs *FieldErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if len(sf.PkgPath) > 0 && !(sf.Anonymous && fv.Kind() == reflect.Struct) {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+
+		// embedded struct without name
+		if sf.Anonymous && len(sf.Tag.Get("form")) == 0 && fv.Kind() == reflect.Struct {
+			bindForm(fv, prefix, form, files, errs)
+			continue
+		}
+		key := prefix + name
+
+		// files
+		switch {
+		case sf.Type == fileHeaderType:
+			if fhs := files[key]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case sf.Type.Kind() == reflect.Slice && sf.Type.Elem() == fileHeaderType:
+			if fhs := files[key]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		// nested struct, pointer is created if any field given
+		if st := derefType(sf.Type); st.Kind() == reflect.Struct && !isScalar(st) {
+			if fv.Kind() == reflect.Ptr {
+				if !hasPrefixKey(form, key+".") {
+					continue
+				}
+				if fv.IsNil() {
+					fv.Set(reflect.New(st))
+				}
+				fv = fv.Elem()
+			}
+			bindForm(fv, key+".", form, files, errs)
+			continue
+		}
+
+		// values, "tags" or "tags[]"
+		vals, ok := form[key]
+		if !ok {
+			vals, ok = form[key+"[]"]
+		}
+		if !ok {
+			continue
+		}
+		if err := setField(fv, vals, sf.Tag.Get("format")); err != nil {
+			*errs = append(*errs, &FieldError{
+				Field: key,
+				Value: strings.Join(vals, ","),
+				Err:   err,
+			})
+		}
+	}
+}
+
+// Set default values of zero fields, nested structs too
+func setDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if len(sf.PkgPath) > 0 && !(sf.Anonymous && fv.Kind() == reflect.Struct) {
+			continue
+		}
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			if !fv.IsZero() {
+				continue
+			}
+			vals := []string{def}
+			if fv.Kind() == reflect.Slice {
+				vals = strings.Split(def, ",")
+			}
+			if err := setField(fv, vals, sf.Tag.Get("format")); err != nil {
+				return fmt.Errorf("Bind: invalid default of %s, %v", sf.Name, err)
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Struct && !isScalar(fv.Type()) {
+			if err := setDefaults(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Name in form, by "form" tag, "json" tag or field name
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"form", "json"} {
+		if name := strings.Split(sf.Tag.Get(tag), ",")[0]; len(name) > 0 {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// Form has key starts with prefix
+func hasPrefixKey(form url.Values, prefix string) bool {
+	for k := range form {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Type pointed to if pointer
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// Set field by values, slice gets all and others the first
+func setField(v reflect.Value, vals []string, format string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(s.Index(i), val, format); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setValue(v, vals[0], format)
+}
+
+// Set value from string, pointer is created
+func setValue(v reflect.Value, s string, format string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), s, format); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	switch v.Type() {
+	case timeType:
+		if len(format) == 0 {
+			format = time.RFC3339
+		}
+		tm, err := time.Parse(format, s)
+		if err != nil {
+			return fmt.Errorf("invalid time, format is %s", format)
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("invalid duration")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		v.SetBytes([]byte(s))
+		return nil
+	}
+	if !isScalar(v.Type()) {
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return setScalar(v, s)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Type can be set from string
+func isScalar(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Set scalar value from string
+func setScalar(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("invalid bool")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid unsigned integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid number")
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
@@ -67,7 +67,7 @@ func (g *Gzip) Handle(c *Context) int {
 		return NEXT_CONTINUE
 	}
 	// small body
-	if len(c.Res.Body) < GZIP_THRESHOLD {
+	if len(c.Res.Body) < c.Options().GzipThreshold {
 		return NEXT_CONTINUE
 	}
 	// empty status
@@ -0,0 +1,237 @@
+package uweb
+
+import (
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/config"
+)
+
+//
+// Config loaded from ini file, options in the section
+// named by env override those in DEFAULT section, and
+// ${ENV_VAR} or ${ENV_VAR:-default} will be replaced by
+// environment variables. For example:
+//
+//	[DEFAULT]
+//	listen = :9090
+//	cache.driver = memcache
+//	cache.dsn = ${MEMCACHE_ADDR:-localhost:11211}
+//	session.expire = 1209600
+//
+//	[dev]
+//	debug = true
+//	development = true
+//
+//	[prod]
+//	debug = false
+//
+type Config struct {
+	env string
+	cfg *config.Config
+}
+
+// Load config file, env is section name such as "dev",
+// "staging" or "prod", empty env means DEFAULT only
+func LoadConfig(path, env string) (*Config, error) {
+	cfg, err := config.ReadDefault(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(env) > 0 && !cfg.HasSection(env) {
+		return nil, errors.New("Config: no section " + env)
+	}
+	return &Config{
+		env: env,
+		cfg: cfg,
+	}, nil
+}
+
+// Get env
+func (c *Config) Env() string {
+	return c.env
+}
+
+// section to read
+func (c *Config) section() string {
+	if len(c.env) == 0 {
+		return config.DEFAULT_SECTION
+	}
+	return c.env
+}
+
+// Key exists in env or DEFAULT section
+func (c *Config) Has(key string) bool {
+	return c.cfg.HasOption(c.section(), key)
+}
+
+// read value and expand env vars
+func (c *Config) value(key string) (string, bool) {
+	v, err := c.cfg.String(c.section(), key)
+	if err != nil {
+		return "", false
+	}
+	return expandEnv(v), true
+}
+
+// Get string value, if not exists return def
+func (c *Config) Str(key, def string) string {
+	if v, ok := c.value(key); ok {
+		return v
+	}
+	return def
+}
+
+// Get string values separated by comma
+func (c *Config) Strs(key string) []string {
+	v, ok := c.value(key)
+	if !ok {
+		return nil
+	}
+	var ss []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// Get int value, if not exists or invalid return def
+func (c *Config) Int(key string, def int) int {
+	v, ok := c.value(key)
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		log.Println(LOG_TAG, "Config: Int err", key, err)
+		return def
+	}
+	return i
+}
+
+// Get bool value, if not exists or invalid return def
+func (c *Config) Bool(key string, def bool) bool {
+	v, ok := c.value(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Println(LOG_TAG, "Config: Bool err", key, err)
+		return def
+	}
+	return b
+}
+
+// Get duration value such as "30s", if not exists
+// or invalid return def
+func (c *Config) Duration(key string, def time.Duration) time.Duration {
+	v, ok := c.value(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Println(LOG_TAG, "Config: Duration err", key, err)
+		return def
+	}
+	return d
+}
+
+// Create application options, globals are used if not set
+//
+// keys: debug, development, max_middleware, session.cookie_key,
+// session.cookie_domain, i18n.key, compress.threshold, log.tag,
+// shutdown_timeout
+func (c *Config) Options() *Options {
+	o := DefaultOptions()
+	o.Debug = c.Bool("debug", o.Debug)
+	o.Development = c.Bool("development", o.Development)
+	o.MaxMiddleware = c.Int("max_middleware", o.MaxMiddleware)
+	o.SidCookieKey = c.Str("session.cookie_key", o.SidCookieKey)
+	o.SidCookieDomain = c.Str("session.cookie_domain", o.SidCookieDomain)
+	o.LocaleKey = c.Str("i18n.key", o.LocaleKey)
+	o.GzipThreshold = c.Int("compress.threshold", o.GzipThreshold)
+	o.LogTag = c.Str("log.tag", o.LogTag)
+	o.ShutdownTimeout = c.Duration("shutdown_timeout", o.ShutdownTimeout)
+	return o
+}
+
+// Add standard middlewares configured in cfg, in order:
+//
+//	ignore.paths              MdIgnore, paths separated by comma
+//	favicon                   MdFavicon
+//	static.prefix/root        MdStatic
+//	compress                  MdCompress, if true
+//	log.level                 MdLogger
+//	cache.driver/dsn          MdCache
+//	session.expire            MdSession
+//	flash                     MdFlash, if true
+//	i18n.root/locale/detect   MdI18n
+//	csrf                      MdCsrf, if true
+//	render.root/suffix        MdRender
+//	redirect                  MdRedirect, if true
+//
+// Error pages and router should be added after this.
+func (a *Application) UseConfig(c *Config) {
+	if ps := c.Strs("ignore.paths"); len(ps) > 0 {
+		a.Use(MdIgnore(ps))
+	}
+	if p := c.Str("favicon", ""); len(p) > 0 {
+		a.Use(MdFavicon(p))
+	}
+	if c.Has("static.prefix") {
+		a.Use(MdStatic(c.Str("static.prefix", ""), c.Str("static.root", "")))
+	}
+	if c.Bool("compress", false) {
+		a.Use(MdCompress())
+	}
+	if c.Has("log.level") {
+		a.Use(MdLogger(c.Int("log.level", LOG_LEVEL_0)))
+	}
+	if c.Has("cache.driver") {
+		a.Use(MdCache(c.Str("cache.driver", ""), c.Str("cache.dsn", "")))
+	}
+	if c.Has("session.expire") {
+		a.Use(MdSession(c.Int("session.expire", 0)))
+	}
+	if c.Bool("flash", false) {
+		a.Use(MdFlash())
+	}
+	if c.Has("i18n.root") {
+		a.Use(MdI18n(c.Str("i18n.root", ""), c.Str("i18n.locale", ""), c.Bool("i18n.detect", false)))
+	}
+	if c.Bool("csrf", false) {
+		a.Use(MdCsrf())
+	}
+	if c.Has("render.root") {
+		a.Use(MdRender(c.Str("render.root", ""), c.Str("render.suffix", "")))
+	}
+	if c.Bool("redirect", false) {
+		a.Use(MdRedirect())
+	}
+}
+
+// ${NAME} or ${NAME:-default}
+var envRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Replace env vars in s
+func expandEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return envRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		sm := envRegexp.FindStringSubmatch(m)
+		if v, ok := os.LookupEnv(sm[1]); ok && (len(v) > 0 || len(sm[2]) == 0) {
+			return v
+		}
+		return sm[3]
+	})
+}
@@ -65,8 +65,8 @@ func (cf *Csrf) Handle(c *Context) int {
 		// save in session
 		c.Sess.Set(CSRF_SECRET_KEY, secret)
 		c.Sess.Set(CSRF_TOKEN_KEY, token)
-		if DEBUG {
-			log.Println(LOG_TAG, "Csrf: token", token)
+		if o := c.Options(); o.Debug {
+			log.Println(o.LogTag, "Csrf: token", token)
 		}
 
 		// for angular.js
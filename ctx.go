@@ -6,7 +6,9 @@ package uweb
 type Context struct {
 	// middleware
 	app    *Application
+	mws    []Middleware // running chain
 	cursor int
+	prefix string // stripped by mount, for redirect
 
 	// req & res
 	Req *Request
@@ -23,6 +25,10 @@ type Context struct {
 
 	// route
 	Redirect *Redirect
+
+	// params buffer reused to avoid allocation
+	pbuf []param
+	hps  []param // host params, merged by router
 }
 
 // Create empty context, need middleware to
@@ -34,8 +40,24 @@ func NewContext(app *Application) *Context {
 	}
 }
 
+// Matched params in a new map, which is safe to be kept
+// after request finished, only the buffer is reused. It
+// costs one map for each request with params, and nil
+// without params, as before routed.
+func (c *Context) params(ps []param) Params {
+	if len(ps) == 0 {
+		return nil
+	}
+	m := make(Params, len(ps))
+	for _, p := range ps {
+		m[p.key] = p.value
+	}
+	return m
+}
+
 // Reset fields for recycle and reuse
 func (c *Context) Reset() {
+	c.mws = nil
 	c.cursor = -1
 
 	c.Req = nil
@@ -48,17 +70,33 @@ func (c *Context) Reset() {
 	c.Redirect = nil
 }
 
+// Get options of the application
+func (c *Context) Options() *Options {
+	return c.app.opts
+}
+
+// Run nested middlewares, such as route group or sub
+// application, and restore the outer chain even if panic
+func (c *Context) run(mws []Middleware) int {
+	oldMws, oldCursor := c.mws, c.cursor
+	defer func() {
+		c.mws, c.cursor = oldMws, oldCursor
+	}()
+	c.mws, c.cursor = mws, -1
+	return c.Next()
+}
+
 // Next run next middlewares or break out all if
 // one return false
 func (c *Context) Next() int {
 	ret := NEXT_BREAK
-	s := len(c.app.mws)
+	s := len(c.mws)
 	for {
 		c.cursor++
 		if c.cursor >= s {
 			break
 		}
-		md := c.app.mws[c.cursor]
+		md := c.mws[c.cursor]
 		ret = md.Handle(c)
 		if ret != NEXT_CONTINUE {
 			c.cursor = s
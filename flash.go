@@ -1,5 +1,11 @@
 package uweb
 
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
 //
 // Create flash middleware,
 // which depends on session middleware
@@ -52,3 +58,57 @@ func (f *Flash) Pop(k string) string {
 	}
 	return v
 }
+
+// Put field errors, which are translated by locale if
+// they are FieldErrors, and l may be nil
+func (f *Flash) PutErrors(err error, l *Locale) {
+	msgs := make(map[string]string)
+	if es, ok := err.(FieldErrors); ok {
+		msgs = es.Messages(l)
+	} else if err != nil {
+		msgs[""] = err.Error()
+	}
+	f.putJson("errors", msgs)
+}
+
+// Pop field errors, key "" is error not of field
+func (f *Flash) Errors() map[string]string {
+	msgs := make(map[string]string)
+	f.popJson("errors", &msgs)
+	return msgs
+}
+
+// Put old input to fill form again, fields named as
+// password are skipped
+func (f *Flash) PutOld(form url.Values) {
+	old := make(url.Values, len(form))
+	for k, v := range form {
+		if !strings.Contains(strings.ToLower(k), "password") {
+			old[k] = v
+		}
+	}
+	f.putJson("old", old)
+}
+
+// Pop old input
+func (f *Flash) Old() url.Values {
+	old := make(url.Values)
+	f.popJson("old", &old)
+	return old
+}
+
+// Put value as json
+func (f *Flash) putJson(k string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	f.Put(k, string(data))
+}
+
+// Pop json value, v is kept if not found
+func (f *Flash) popJson(k string, v interface{}) {
+	if data := f.Pop(k); len(data) > 0 {
+		json.Unmarshal([]byte(data), v)
+	}
+}
@@ -0,0 +1,212 @@
+package uweb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrFnResult = errors.New("Fn: result should be (), (error), (v) or (v, error)")
+)
+
+// Typed handler of default router
+func Fn(method, p string, f interface{}) *Route {
+	return defaultRouter.Fn(method, p, f)
+}
+
+// Add typed handler, such as:
+//
+//	r.Fn("GET", "/users/:id", func(c *uweb.Context, id int64, q Query) (interface{}, error) {
+//		...
+//	})
+//
+// Args are *Context, scalars bound to path params in
+// pattern order, and structs bound by Req.Bind. Scalars
+// are only for path params, it panics if more scalars
+// than params, so use a struct for query and form.
+// Conversion failure responses 400. Result is sent
+// by Response.Json, error by its status or 500.
+func (r *Router) Fn(method, p string, f interface{}) *Route {
+	return r.addLeaf(method, p, newFnLeaf(p, f, nil))
+}
+
+func (g *RGroup) Fn(method, p string, f interface{}) *Route {
+	return g.r.addLeaf(method, g.prefix+p, newFnLeaf(g.prefix+p, f, g.mws))
+}
+
+// leaf of typed handler, named by f for listing
+func newFnLeaf(p string, f interface{}, mws []Middleware) *RNode {
+	leaf := newLeaf(p, fnHandler(p, f), mws)
+	leaf.hname = funcName(f)
+	return leaf
+}
+
+//
+// Error with http status, typed handler returns it
+// to response other than 500
+//
+type HttpError struct {
+	Status int
+	Err    error
+}
+
+// Err, or status text if no Err
+func (e *HttpError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+// Bad request error
+func BadRequest(err error) *HttpError {
+	return &HttpError{
+		Status: 400,
+		Err:    err,
+	}
+}
+
+// arg binder, fills arg from request
+type fnArg func(c *Context) (reflect.Value, error)
+
+var (
+	ctxType = reflect.TypeOf((*Context)(nil))
+	errType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Wrap typed function as handler, panic if f is invalid
+func fnHandler(p string, f interface{}) HttpHandler {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		panic("Fn: not func of " + p)
+	}
+
+	// args
+	keys := patternKeys(p)
+	args := make([]fnArg, ft.NumIn())
+	for i := 0; i < ft.NumIn(); i++ {
+		t := ft.In(i)
+		switch {
+		case t == ctxType:
+			args[i] = func(c *Context) (reflect.Value, error) {
+				return reflect.ValueOf(c), nil
+			}
+		case isScalar(t):
+			if len(keys) == 0 {
+				panic(fmt.Sprintf("Fn: no param for arg %d of %s, use struct for query and form", i, p))
+			}
+			args[i] = paramArg(keys[0], t)
+			keys = keys[1:]
+		case t.Kind() == reflect.Struct || (t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct):
+			args[i] = structArg(t)
+		default:
+			panic(fmt.Sprintf("Fn: unsupported arg %s of %s", t, p))
+		}
+	}
+
+	// results
+	hasValue, hasErr := false, false
+	switch ft.NumOut() {
+	case 0:
+	case 1:
+		hasErr = ft.Out(0) == errType
+		hasValue = !hasErr
+	case 2:
+		if ft.Out(1) != errType {
+			panic(ErrFnResult)
+		}
+		hasValue, hasErr = true, true
+	default:
+		panic(ErrFnResult)
+	}
+
+	return func(c *Context) {
+		// args
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			v, err := arg(c)
+			if err != nil {
+				fnError(c, err)
+				return
+			}
+			in[i] = v
+		}
+
+		// call
+		out := fv.Call(in)
+		if hasErr {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				fnError(c, err)
+				return
+			}
+		}
+		if hasValue {
+			if err := c.Res.Json(c.Res.Status, out[0].Interface()); err != nil {
+				fnError(c, err)
+			}
+		}
+	}
+}
+
+// Response error by status, 500 if not HttpError
+func fnError(c *Context, err error) {
+	c.Res.Status = 500
+	if he, ok := err.(*HttpError); ok {
+		c.Res.Status = he.Status
+	}
+	c.Res.Err = err
+}
+
+// Bind path param to scalar arg
+func paramArg(key string, t reflect.Type) fnArg {
+	return func(c *Context) (reflect.Value, error) {
+		v := reflect.New(t).Elem()
+		if err := setScalar(v, c.Req.Params[key]); err != nil {
+			return v, BadRequest(fmt.Errorf("Fn: invalid param %s, %v", key, err))
+		}
+		return v, nil
+	}
+}
+
+// Bind request to struct arg, 415 if unsupported
+// content type, and 400 for others
+func structArg(t reflect.Type) fnArg {
+	ptr := t.Kind() == reflect.Ptr
+	if ptr {
+		t = t.Elem()
+	}
+	return func(c *Context) (reflect.Value, error) {
+		v := reflect.New(t)
+		if err := c.Req.Bind(v.Interface()); err == ErrBindType {
+			return v, &HttpError{Status: 415, Err: err}
+		} else if err != nil {
+			return v, BadRequest(err)
+		}
+		if ptr {
+			return v, nil
+		}
+		return v.Elem(), nil
+	}
+}
+
+// Path param keys in pattern order
+func patternKeys(p string) []string {
+	var keys []string
+	for _, seg := range strings.Split(strings.Trim(p, "/"), "/") {
+		if len(seg) == 0 {
+			continue
+		}
+		n, err := newRNode(seg)
+		if err != nil {
+			panic(err)
+		}
+		if n.isParam() || n.isWild() {
+			keys = append(keys, n.key())
+		}
+	}
+	return keys
+}
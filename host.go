@@ -0,0 +1,191 @@
+package uweb
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrHostNotFound = errors.New("HostRouter: host not found")
+	ErrBadHost      = errors.New("HostRouter: bad host pattern")
+)
+
+//
+// Create host router, def is used if no host matched,
+// and 404 if def is nil, such as
+//
+//	hosts := uweb.NewHostRouter(uweb.DefaultRouter())
+//	admin := hosts.Host("admin.example.com")
+//	tenant := hosts.Host("{tenant}.example.com")
+//	app.Use(hosts)
+//
+func NewHostRouter(def *Router) *HostRouter {
+	return &HostRouter{
+		def: def,
+	}
+}
+
+//
+// HostRouter selects router by request host, host params
+// such as "{tenant}" are merged into Req.Params, and path
+// params with the same name win.
+//
+type HostRouter struct {
+	mu    sync.Mutex   // for adding
+	hosts atomic.Value // []*hostRoute, sorted, copy on write
+	def   *Router
+}
+
+// host pattern and its router
+type hostRoute struct {
+	pattern string
+	labels  []string // "{name}" or literal, lower case
+	shape   string   // pattern without param names
+	params  int      // count of params
+	r       *Router
+}
+
+func (h *HostRouter) Name() string {
+	return "host"
+}
+
+// Get router of host pattern, create it if not exists.
+// Literal labels win, then pattern with less params, then
+// the first added.
+func (h *HostRouter) Host(pattern string) *Router {
+	hr, err := newHostRoute(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	old := h.routes()
+	for _, o := range old {
+		if o.pattern == hr.pattern {
+			return o.r
+		}
+		if o.shape == hr.shape {
+			panic("HostRouter: conflict host " + pattern + " with " + o.pattern)
+		}
+	}
+
+	// insert after those with less or equal params
+	hr.r = NewRouter()
+	hosts := make([]*hostRoute, 0, len(old)+1)
+	i := 0
+	for ; i < len(old) && old[i].params <= hr.params; i++ {
+		hosts = append(hosts, old[i])
+	}
+	hosts = append(hosts, hr)
+	hosts = append(hosts, old[i:]...)
+	h.hosts.Store(hosts)
+	return hr.r
+}
+
+// sorted host routes
+func (h *HostRouter) routes() []*hostRoute {
+	hosts, _ := h.hosts.Load().([]*hostRoute)
+	return hosts
+}
+
+// @impl Middleware
+func (h *HostRouter) Handle(c *Context) int {
+	// match host, params buffer is reused by context
+	host := hostName(c.Req.Host)
+	ps := c.hps[:0]
+	r := h.def
+	for _, hr := range h.routes() {
+		if hr.match(host, &ps) {
+			r = hr.r
+			break
+		}
+		ps = ps[:0]
+	}
+	if r == nil {
+		c.Res.Status = 404
+		c.Res.Err = ErrHostNotFound
+		return NEXT_BREAK
+	}
+
+	// route with host params
+	c.hps = ps
+	defer func() {
+		c.hps = c.hps[:0]
+	}()
+	return r.Handle(c)
+}
+
+// Parse host pattern, such as "{tenant}.example.com"
+func newHostRoute(pattern string) (*hostRoute, error) {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	if len(pattern) == 0 {
+		return nil, ErrBadHost
+	}
+	hr := &hostRoute{
+		pattern: pattern,
+		labels:  strings.Split(pattern, "."),
+	}
+	shape := make([]string, len(hr.labels))
+	for i, l := range hr.labels {
+		switch {
+		case len(l) == 0:
+			return nil, ErrBadHost
+		case l[0] == '{':
+			if len(l) < 3 || l[len(l)-1] != '}' || strings.ContainsAny(l[1:len(l)-1], "{}") {
+				return nil, ErrBadHost
+			}
+			hr.params++
+			shape[i] = "{}"
+		case strings.ContainsAny(l, "{}"):
+			return nil, ErrBadHost
+		default:
+			shape[i] = l
+		}
+	}
+	hr.shape = strings.Join(shape, ".")
+	return hr, nil
+}
+
+// Match host label by label, params appended to ps
+func (hr *hostRoute) match(host string, ps *[]param) bool {
+	for i, l := range hr.labels {
+		// label
+		var label string
+		if i == len(hr.labels)-1 {
+			label, host = host, ""
+		} else if j := strings.IndexByte(host, '.'); j >= 0 {
+			label, host = host[:j], host[j+1:]
+		} else {
+			return false
+		}
+
+		// compare
+		if len(label) == 0 || strings.IndexByte(label, '.') >= 0 {
+			return false
+		}
+		if l[0] == '{' {
+			*ps = append(*ps, param{l[1 : len(l)-1], label})
+		} else if l != label {
+			return false
+		}
+	}
+	return true
+}
+
+// "Admin.Example.com:8080" -> "admin.example.com"
+func hostName(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(host, ".")
+	for i := 0; i < len(host); i++ {
+		if c := host[i]; 'A' <= c && c <= 'Z' {
+			return strings.ToLower(host)
+		}
+	}
+	return host
+}
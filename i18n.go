@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/robfig/config"
 )
@@ -38,9 +39,6 @@ func MdI18n(root, locale string, detect bool) Middleware {
 		if err != nil {
 			return err
 		}
-		if DEBUG {
-			log.Printf("%s I18n: Walk path-%s, name-%s\n", LOG_TAG, path, info.Name())
-		}
 		cfgs[filepath.Base(info.Name())] = cfg
 		return nil
 	})
@@ -69,23 +67,37 @@ func (i *I18n) Name() string {
 	return "i18n"
 }
 
+// Log locales by options of app
+func (i *I18n) bind(a *Application) {
+	if !a.opts.Debug {
+		return
+	}
+	codes := make([]string, 0, len(i.cfgs))
+	for code := range i.cfgs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	log.Println(a.opts.LogTag, "I18n: locales", codes, "fallback", i.locale)
+}
+
 // @impl Middleware
 func (i *I18n) Handle(c *Context) int {
 	code := ""
+	key := c.Options().LocaleKey
 
 	// detect in order
 	if i.detect {
 		// 1. from query
-		if q := c.Req.FormValue(LOCALE_KEY); len(q) > 0 {
+		if q := c.Req.FormValue(key); len(q) > 0 {
 			code = q
 		} else {
 			// 2. from cookie
-			if k, err := c.Req.Cookie(LOCALE_KEY); err == nil && k != nil && len(k.Value) > 0 {
+			if k, err := c.Req.Cookie(key); err == nil && k != nil && len(k.Value) > 0 {
 				code = k.Value
 			} else {
 				// 3. from session
 				if c.Sess != nil {
-					if v := c.Sess.Get(LOCALE_KEY); len(v) > 0 {
+					if v := c.Sess.Get(key); len(v) > 0 {
 						code = v
 					}
 				}
@@ -99,7 +111,7 @@ func (i *I18n) Handle(c *Context) int {
 	}
 
 	// c
-	c.Locale = &Locale{code: code, i18n: i}
+	c.Locale = &Locale{code: code, i18n: i, opts: c.Options()}
 	return NEXT_CONTINUE
 }
 
@@ -109,6 +121,7 @@ func (i *I18n) Handle(c *Context) int {
 type Locale struct {
 	code string
 	i18n *I18n
+	opts *Options
 }
 
 // Get locale code
@@ -121,8 +134,8 @@ func (l *Locale) Str(section, key string) string {
 	// data
 	data, ok := l.i18n.cfgs[l.i18n.locale]
 	if !ok {
-		if DEBUG {
-			log.Println(LOG_TAG, "I18n: not found value in locale files, check section and key")
+		if l.opts.Debug {
+			log.Println(l.opts.LogTag, "I18n: not found value in locale files, check section and key")
 		}
 		return ""
 	}
@@ -134,3 +147,18 @@ func (l *Locale) Str(section, key string) string {
 	}
 	return value
 }
+
+// Get string value if found in locale, then fallback
+// locale, used by validation messages
+func (l *Locale) Lookup(section, key string) (string, bool) {
+	for _, code := range []string{l.code, l.i18n.locale} {
+		data, ok := l.i18n.cfgs[code]
+		if !ok || !data.HasOption(section, key) {
+			continue
+		}
+		if v, err := data.String(section, key); err == nil {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+package uweb
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// first fd passed by systemd, see sd_listen_fds(3)
+const (
+	SD_LISTEN_FDS_START = 3
+)
+
+var (
+	ErrSocketInUse = errors.New("Application: socket in use")
+	ErrNotSocket   = errors.New("Application: file exists and not socket")
+	ErrNoListener  = errors.New("Application: no listener")
+)
+
+// Serve on listener created by others, ln will be
+// closed when shutdown. It is passed to child when
+// restart, get it by InheritedListener with ln.Addr().
+func (a *Application) Serve(ln net.Listener) error {
+	if a.opts.Debug {
+		log.Println(a.opts.LogTag, "Application: serve at", ln.Addr())
+	}
+	a.keep(ln.Addr().Network()+":"+ln.Addr().String(), ln)
+	return a.serve(&http.Server{Handler: a}, ln)
+}
+
+// Listen on unix domain socket and chmod it to mode,
+// stale socket file left by crashed process will be removed
+func (a *Application) ListenUnix(path string, mode os.FileMode) error {
+	key := "unix:" + path
+	ln, ok := takeInherited(key)
+	if !ok {
+		var err error
+		if ln, err = listenUnix(path, mode); err != nil {
+			return err
+		}
+	}
+	a.keep(key, ln)
+	return a.Serve(ln)
+}
+
+// Serve on all listeners passed by systemd socket activation,
+// return when all stopped or the first error
+func (a *Application) ListenSystemd() error {
+	// from systemd or parent if restarted
+	lns, err := SystemdListeners()
+	if err != nil {
+		return err
+	}
+	if len(lns) == 0 {
+		lns = takeInheritedPrefix("systemd:")
+	}
+	if len(lns) == 0 {
+		return ErrNoListener
+	}
+	for _, ln := range lns {
+		a.keep("systemd:"+ln.Addr().String(), ln)
+	}
+
+	errc := make(chan error, len(lns))
+	for _, ln := range lns {
+		go func(ln net.Listener) {
+			errc <- a.Serve(ln)
+		}(ln)
+	}
+	for range lns {
+		if err := <-errc; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Listen unix socket, remove the stale one
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	// check exists
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, ErrNotSocket
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, ErrSocketInUse
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// listen, socket file will be removed when closed
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Get listeners passed by systemd, nil if not activated
+// by systemd. Envs will be unset to not pass to children.
+func SystemdListeners() ([]net.Listener, error) {
+	// check envs
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	// FileListener dup the fd, so close the file
+	lns := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		fd := SD_LISTEN_FDS_START + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && len(names[i]) > 0 {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, ln := range lns {
+				ln.Close()
+			}
+			return nil, err
+		}
+		lns = append(lns, ln)
+	}
+	return lns, nil
+}
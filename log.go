@@ -56,6 +56,7 @@ func (lg *Logger) Handle(c *Context) int {
 	if lg.level == LOG_LEVEL_0 {
 		return NEXT_CONTINUE
 	}
+	tag := c.Options().LogTag
 
 	reqBody := "\n"
 	if lg.level == LOG_LEVEL_2 {
@@ -66,7 +67,7 @@ func (lg *Logger) Handle(c *Context) int {
 		reqBody = fmt.Sprintf("\n{\n\n%s\n\n}\n", string(dump))
 	}
 
-	log.Printf("%s %s%s %s %s %s", LOG_TAG, c.Req.IP, "-->", c.Req.Method, c.Req.URL.Path, reqBody)
+	log.Printf("%s %s%s %s %s %s", tag, c.Req.IP, "-->", c.Req.Method, c.Req.URL.Path, reqBody)
 
 	start := time.Now()
 	c.Next()
@@ -82,7 +83,7 @@ func (lg *Logger) Handle(c *Context) int {
 		}
 		resBody = fmt.Sprintf("\n{\n\n%s\n\n}\n", dump)
 	}
-	log.Printf("%s %s%s %s %s %d %d(byte) %d(ms) %s", LOG_TAG, c.Req.IP, "<--", c.Req.Method, c.Req.URL.Path, c.Res.Status, size, spend, resBody)
+	log.Printf("%s %s%s %s %s %d %d(byte) %d(ms) %s", tag, c.Req.IP, "<--", c.Req.Method, c.Req.URL.Path, c.Res.Status, size, spend, resBody)
 
 	return NEXT_CONTINUE
 }
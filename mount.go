@@ -0,0 +1,79 @@
+package uweb
+
+import (
+	"strings"
+)
+
+// Mount sub application under prefix, which has its own
+// middlewares and options, and sees path without prefix.
+// Middlewares added before Mount, such as error pages,
+// wrap the sub application, and those after are skipped
+// if path matched.
+func (a *Application) Mount(prefix string, sub *Application) {
+	prefix = strings.TrimRight(prefix, "/")
+	if len(prefix) == 0 || prefix[0] != '/' {
+		panic("Application: invalid mount prefix")
+	}
+	a.Use(&mount{
+		prefix: prefix,
+		app:    sub,
+	})
+}
+
+//
+// Mount point
+//
+type mount struct {
+	prefix string
+	app    *Application
+}
+
+func (m *mount) Name() string {
+	return "mount " + m.prefix
+}
+
+// @impl Middleware
+func (m *mount) Handle(c *Context) int {
+	// match prefix at segment boundary
+	p := c.Req.URL.Path
+	if !strings.HasPrefix(p, m.prefix) {
+		return NEXT_CONTINUE
+	}
+	if len(p) > len(m.prefix) && p[len(m.prefix)] != '/' {
+		return NEXT_CONTINUE
+	}
+
+	// run sub app, parent's rest middlewares are skipped
+	if ret := c.mount(m.app, m.prefix); ret == NEXT_ABORT {
+		return ret
+	}
+	return NEXT_BREAK
+}
+
+// Run middlewares of app with prefix stripped path,
+// and restore even if panic
+func (c *Context) mount(app *Application, prefix string) int {
+	// save
+	oldApp, oldURL, oldPrefix := c.app, c.Req.URL, c.prefix
+	defer func() {
+		c.app, c.Req.URL, c.prefix = oldApp, oldURL, oldPrefix
+	}()
+
+	// strip prefix
+	u := *oldURL
+	u.Path = strings.TrimPrefix(u.Path, prefix)
+	if len(u.Path) == 0 {
+		u.Path = "/"
+	}
+	if strings.HasPrefix(u.RawPath, prefix) {
+		u.RawPath = strings.TrimPrefix(u.RawPath, prefix)
+	} else {
+		u.RawPath = ""
+	}
+	c.Req.URL = &u
+	c.prefix = oldPrefix + prefix
+
+	// run
+	c.app = app
+	return c.run(app.mws)
+}
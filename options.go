@@ -0,0 +1,76 @@
+package uweb
+
+import (
+	"time"
+)
+
+//
+// Per application options, so that several applications
+// with different options can run in one process.
+// Start from DefaultOptions, which takes globals such as
+// DEBUG, and change fields. All fields are used as is,
+// zero values are not replaced by globals.
+//
+type Options struct {
+	// debug mode
+	Debug bool
+
+	// development environment, reload templates on each access
+	Development bool
+
+	// maxium middlewares
+	MaxMiddleware int
+
+	// session cookie
+	SidCookieKey    string
+	SidCookieDomain string
+
+	// locale key in query, cookie and session
+	LocaleKey string
+
+	// if body length less than this, no need to compress
+	GzipThreshold int
+
+	// prefix of log
+	LogTag string
+
+	// maxium time to wait active requests when shutdown by signal
+	ShutdownTimeout time.Duration
+}
+
+// Create options from globals
+func DefaultOptions() *Options {
+	return &Options{
+		Debug:           DEBUG,
+		Development:     DEVELOPMENT,
+		MaxMiddleware:   MAX_MIDDLEWARE,
+		SidCookieKey:    SID_COOKIE_KEY,
+		SidCookieDomain: SID_COOKIE_DOMAIN,
+		LocaleKey:       LOCALE_KEY,
+		GzipThreshold:   GZIP_THRESHOLD,
+		LogTag:          LOG_TAG,
+		ShutdownTimeout: SHUTDOWN_TIMEOUT,
+	}
+}
+
+// Copy options to keep app's own, and panic if invalid,
+// nil for DefaultOptions
+func (o *Options) check() *Options {
+	if o == nil {
+		return DefaultOptions()
+	}
+	switch {
+	case o.MaxMiddleware <= 0:
+		panic("Options: MaxMiddleware should be positive")
+	case len(o.SidCookieKey) == 0:
+		panic("Options: empty SidCookieKey")
+	case len(o.LocaleKey) == 0:
+		panic("Options: empty LocaleKey")
+	case o.GzipThreshold < 0:
+		panic("Options: negative GzipThreshold")
+	case o.ShutdownTimeout < 0:
+		panic("Options: negative ShutdownTimeout")
+	}
+	v := *o
+	return &v
+}
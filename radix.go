@@ -0,0 +1,168 @@
+package uweb
+
+import (
+	"regexp"
+	"strings"
+)
+
+// kinds of frozen node
+const (
+	kindLiteral = iota
+	kindParam
+	kindWild
+)
+
+//
+// Frozen node of RTree, which is read only and so can be
+// matched without lock. Literal chains without handler are
+// compressed into one node, such as "api/v2/users".
+//
+type fnode struct {
+	kind    int
+	prefix  string         // literal segments joined by "/"
+	key     string         // params key
+	re      *regexp.Regexp // param constraint
+	handler HttpHandler
+	chain   []Middleware
+	path    string   // full pattern of handler
+	child   []*fnode // in order of RNode.rank
+}
+
+// Matched param, collected in slice to avoid map allocation
+type param struct {
+	key   string
+	value string
+}
+
+// Freeze root, which matches nothing
+func freezeRoot(n *RNode) *fnode {
+	f := &fnode{
+		handler: n.handler,
+		chain:   n.chain,
+		path:    n.path,
+	}
+	for _, c := range n.child {
+		f.child = append(f.child, freeze(c))
+	}
+	return f
+}
+
+// Freeze node and its subtree
+func freeze(n *RNode) *fnode {
+	f := &fnode{
+		handler: n.handler,
+		chain:   n.chain,
+		path:    n.path,
+	}
+	switch {
+	case n.isWild():
+		f.kind, f.key = kindWild, n.key()
+	case n.isParam():
+		f.kind, f.key, f.re = kindParam, n.key(), n.re
+	default:
+		f.kind, f.prefix = kindLiteral, n.pattern
+		// compress literal chain
+		for n.handler == nil && len(n.child) == 1 && n.child[0].rank() == 0 {
+			n = n.child[0]
+			f.prefix += "/" + n.pattern
+			f.handler, f.chain, f.path = n.handler, n.chain, n.path
+		}
+	}
+	for _, c := range n.child {
+		f.child = append(f.child, freeze(c))
+	}
+	return f
+}
+
+// Match rest of path by children, rest has no leading "/",
+// and ps collects params on the way. Literals are matched
+// case-insensitively if fold.
+func (f *fnode) match(rest string, ps *[]param, fold bool) *fnode {
+	// path ends here, or wildcard child matches empty rest
+	if len(rest) == 0 {
+		if f.handler != nil {
+			return f
+		}
+		for _, c := range f.child {
+			if c.kind == kindWild && c.handler != nil {
+				*ps = append(*ps, param{c.key, ""})
+				return c
+			}
+		}
+		return nil
+	}
+
+	// children in order of priority
+	for _, c := range f.child {
+		switch c.kind {
+		case kindLiteral:
+			if !hasPrefix(rest, c.prefix, fold) {
+				continue
+			}
+			r := rest[len(c.prefix):]
+			if len(r) > 0 {
+				if r[0] != '/' {
+					continue
+				}
+				r = r[1:]
+			}
+			if m := c.match(r, ps, fold); m != nil {
+				return m
+			}
+		case kindParam:
+			seg, r := rest, ""
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				seg, r = rest[:i], rest[i+1:]
+			}
+			if c.re != nil && !c.re.MatchString(seg) {
+				continue
+			}
+			*ps = append(*ps, param{c.key, seg})
+			if m := c.match(r, ps, fold); m != nil {
+				return m
+			}
+			*ps = (*ps)[:len(*ps)-1]
+		case kindWild:
+			if c.handler != nil {
+				*ps = append(*ps, param{c.key, rest})
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// Get frozen tree, freeze it if changed after last freeze
+func (rt *RTree) tree() *fnode {
+	if f, _ := rt.frozen.Load().(*fnode); f != nil {
+		return f
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if f, _ := rt.frozen.Load().(*fnode); f != nil {
+		return f
+	}
+	f := freezeRoot(rt.root)
+	rt.frozen.Store(f)
+	return f
+}
+
+// Match path without lock, params appended to ps
+func (rt *RTree) lookup(p string, ps *[]param) *fnode {
+	return rt.tree().match(strings.Trim(p, "/"), ps, false)
+}
+
+// Match path case-insensitively
+func (rt *RTree) lookupFold(p string, ps *[]param) *fnode {
+	return rt.tree().match(strings.Trim(p, "/"), ps, true)
+}
+
+// s starts with prefix, ignore case if fold
+func hasPrefix(s, prefix string, fold bool) bool {
+	if !fold {
+		return strings.HasPrefix(s, prefix)
+	}
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,128 @@
+package uweb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http/httputil"
+	"runtime/debug"
+)
+
+var (
+	ErrPanic = errors.New("Recover: internal server error")
+)
+
+//
+// Create recover middleware, should be the first one
+//
+func MdRecover() Middleware {
+	return new(Recovery)
+}
+
+//
+// Recover from panic in middlewares and handlers after it,
+// and response 500. In development, response a page with
+// stack, request, session keys and middlewares.
+//
+type Recovery struct {
+	// empty
+}
+
+func (r *Recovery) Name() string {
+	return "recover"
+}
+
+// @impl Middleware
+func (r *Recovery) Handle(c *Context) (ret int) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		stack := debug.Stack()
+		o := c.Options()
+		log.Printf("%s Recover: panic %v\n%s", o.LogTag, v, stack)
+
+		// skip middlewares not run yet
+		c.cursor = len(c.mws)
+		ret = NEXT_BREAK
+
+		// response
+		if !o.Development {
+			c.Res.Status = 500
+			c.Res.Err = ErrPanic
+			return
+		}
+		page, err := r.page(c, v, stack)
+		if err != nil {
+			c.Res.Status = 500
+			c.Res.Err = err
+			return
+		}
+		c.Res.Err = nil
+		c.Res.Html(500, page)
+	}()
+
+	c.Next()
+	return NEXT_CONTINUE
+}
+
+// Render development page
+func (r *Recovery) page(c *Context, v interface{}, stack []byte) ([]byte, error) {
+	// request
+	dump, err := httputil.DumpRequest(c.Req.Request, false)
+	if err != nil {
+		dump = []byte(err.Error())
+	}
+
+	// session
+	var keys []string
+	if c.Sess != nil {
+		keys = c.Sess.Keys()
+	}
+
+	// middlewares
+	var mws []string
+	for _, m := range c.mws {
+		mws = append(mws, m.Name())
+	}
+
+	// render
+	buf := new(bytes.Buffer)
+	if err := recoverTpl.Execute(buf, Map{
+		"panic":   fmt.Sprint(v),
+		"stack":   string(stack),
+		"request": string(dump),
+		"keys":    keys,
+		"mws":     mws,
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var recoverTpl = template.Must(template.New("recover").Parse(`<!doctype html>
+<html>
+  <head>
+	<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+	<title>500 panic: {{.panic}}</title>
+	<style>
+	  body { font-family: sans-serif; margin: 2em; }
+	  pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+	</style>
+  </head>
+  <body>
+	<h1>panic: {{.panic}}</h1>
+	<h2>Stack</h2>
+	<pre>{{.stack}}</pre>
+	<h2>Request</h2>
+	<pre>{{.request}}</pre>
+	<h2>Session keys</h2>
+	<ul>{{range .keys}}<li>{{.}}</li>{{else}}<li>no session</li>{{end}}</ul>
+	<h2>Middlewares</h2>
+	<ol>{{range .mws}}<li>{{.}}</li>{{end}}</ol>
+  </body>
+</html>
+`))
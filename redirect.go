@@ -37,15 +37,28 @@ type Redirect struct {
 	c *Context
 }
 
-// Redirect to url
+// Redirect to url. In mounted app, relative url is
+// resolved against the full path, and absolute path such
+// as "/login" is under the mount prefix, so use url with
+// scheme and host to leave the mounted app.
 func (r *Redirect) To(urlStr string) {
+	r.redirect(302, urlStr)
+}
+
+// Redirect to url permanently
+func (r *Redirect) Permanent(urlStr string) {
+	r.redirect(301, urlStr)
+}
+
+// Set location and status
+func (r *Redirect) redirect(status int, urlStr string) {
 	// req, res
 	req, res := r.c.Req, r.c.Res
 
 	// copy from http/server.go
 	// Location should be an absolute URI, like
 	if u, err := url.Parse(urlStr); err == nil {
-		oldpath := req.URL.Path
+		oldpath := r.c.prefix + req.URL.Path
 		if oldpath == "" { // should not happen, but avoid a crash if it does
 			oldpath = "/"
 		}
@@ -55,6 +68,9 @@ func (r *Redirect) To(urlStr string) {
 				// make relative path absolute
 				olddir, _ := path.Split(oldpath)
 				urlStr = olddir + urlStr
+			} else if u.Host == "" {
+				// absolute path is in mounted app
+				urlStr = r.c.prefix + urlStr
 			}
 			var query string
 			if i := strings.Index(urlStr, "?"); i != -1 {
@@ -73,7 +89,7 @@ func (r *Redirect) To(urlStr string) {
 	// RFC2616 recommends that a short note "SHOULD" be included in the
 	// response because older user agents may not understand 301/307.
 	// Shouldn't send the response for POST or HEAD; that leaves GET.
-	res.Status = 302
+	res.Status = status
 	res.Header().Set("Location", urlStr)
 	if req.Method == "GET" {
 		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -81,11 +97,36 @@ func (r *Redirect) To(urlStr string) {
 	}
 }
 
-// Back to referrer or "/"
+// Back to referrer or "/", which is the mount prefix in
+// mounted app
 func (r *Redirect) Back() {
-	urlStr := r.c.Req.Header.Get("Referrer")
+	urlStr := r.c.Req.Referer()
+	if len(urlStr) == 0 {
+		urlStr = r.c.Req.Header.Get("Referrer")
+	}
 	if len(urlStr) == 0 {
 		urlStr = "/"
 	}
 	r.To(urlStr)
 }
+
+// Back with errors and old input in flash, for form
+// page to show them, such as:
+//
+//	if err := c.Req.Bind(&form); err != nil {
+//		c.Redirect.BackWith(err)
+//		return
+//	}
+//
+func (r *Redirect) BackWith(err error) {
+	c := r.c
+	if c.Flash == nil {
+		panic("Redirect: flash middleware is needed")
+	}
+	c.Flash.PutErrors(err, c.Locale)
+	if c.Req.Form == nil {
+		c.Req.ParseForm()
+	}
+	c.Flash.PutOld(c.Req.Form)
+	r.Back()
+}
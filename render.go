@@ -2,12 +2,14 @@ package uweb
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 //
@@ -22,7 +24,9 @@ type Render interface {
 }
 
 //
-// Create render middleware
+// Create render middleware, templates are loaded when
+// used by app, and reloaded on each render if the app's
+// Development is true
 //
 func MdRender(root, suffix string) Middleware {
 	tpl, err := NewTemplate(root, suffix)
@@ -36,14 +40,27 @@ func MdRender(root, suffix string) Middleware {
 // Default template
 //
 var (
-	tplHelpers = make(map[string]interface{})
+	tplHelpers  = make(map[string]interface{})
+	tplBuiltins = make(map[string]bool) // helpers of uweb, can be replaced
 )
 
-// Register helper to default tpl instance
+// Register helper to default tpl instance, built-in
+// helpers such as "url" are replaced by the user's one
 func Helper(name string, f interface{}) {
-	if _, ok := tplHelpers[name]; ok {
+	if _, ok := tplHelpers[name]; ok && !tplBuiltins[name] {
 		panic("Template: DUP helper")
 	}
+	delete(tplBuiltins, name)
+	tplHelpers[name] = f
+}
+
+// Register built-in helper, ignore if the user's one
+// registered already
+func builtinHelper(name string, f interface{}) {
+	if _, ok := tplHelpers[name]; ok {
+		return
+	}
+	tplBuiltins[name] = true
 	tplHelpers[name] = f
 }
 
@@ -53,22 +70,71 @@ func Helper(name string, f interface{}) {
 type Template struct {
 	root   string
 	suffix string
-	tpl    *template.Template
+	opts   *Options // of app used by, set by Use
+
+	mu    sync.Mutex
+	tpl   *template.Template               // parsed, never executed to clone
+	views map[tplView]*template.Template // cloned for each app and mount
+}
+
+// app and mount prefix which url helper resolves by,
+// nil app for global URL
+type tplView struct {
+	app    *Application
+	prefix string
+}
+
+// url helper of app, with mount prefix
+func (v tplView) url(name string, pairs ...interface{}) (string, error) {
+	u, err := v.app.URL(name, pairs...)
+	if err != nil {
+		return "", err
+	}
+	return v.prefix + u, nil
 }
 
-// Create empty object
+// Create empty object, templates are loaded when used
+// by app if not Development, or on first execute
 func NewTemplate(root, suffix string) (*Template, error) {
+	// root
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("Template: not dir " + root)
+	}
+
 	// tpl
 	t := &Template{
 		root:   root,
 		suffix: suffix,
 	}
-	if !DEVELOPMENT {
+	return t, nil
+}
+
+// Options of app used by, globals if not used
+func (t *Template) options() *Options {
+	if t.opts != nil {
+		return t.opts
+	}
+	return DefaultOptions()
+}
+
+// Take options of the first app, and load templates if
+// not Development
+func (t *Template) bind(a *Application) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.opts == nil {
+		t.opts = a.opts
+	}
+	if !a.opts.Development && t.tpl == nil {
 		if err := t.loadTpls(); err != nil {
-			return nil, err
+			panic(err)
 		}
 	}
-	return t, nil
 }
 
 func (t *Template) loadTpls() error {
@@ -95,8 +161,8 @@ func (t *Template) loadTpls() error {
 
 		if match {
 			files = append(files, path)
-			if DEBUG {
-				log.Println(LOG_TAG, "Template: parse file ", path, filepath.Base(path))
+			if opts := t.options(); opts.Debug {
+				log.Println(opts.LogTag, "Template: parse file ", path, filepath.Base(path))
 			}
 		}
 		return nil
@@ -104,14 +170,18 @@ func (t *Template) loadTpls() error {
 		return err
 	}
 
-	// parse
-	tpl, err := template.ParseFiles(files...)
+	// parse with helpers
+	if len(files) == 0 {
+		return errors.New("Template: no files in " + t.root)
+	}
+	tpl, err := template.New(filepath.Base(files[0])).Funcs(tplHelpers).ParseFiles(files...)
 	if err != nil {
 		return err
 	}
 
 	// ok
 	t.tpl = tpl
+	t.views = make(map[tplView]*template.Template)
 	return nil
 }
 
@@ -125,12 +195,44 @@ func (t *Template) Handle(c *Context) int {
 	return NEXT_CONTINUE
 }
 
-// Execute template
+// Execute template, reload if Development of the app
+// used by, or globals if not used
 func (t *Template) Execute(w io.Writer, name string, data interface{}) error {
-	if DEVELOPMENT {
-		t.loadTpls()
+	return t.execute(t.options().Development, tplView{}, w, name, data)
+}
+
+// Execute template of view, reload if dev
+func (t *Template) execute(dev bool, view tplView, w io.Writer, name string, data interface{}) error {
+	tpl, err := t.load(dev, view)
+	if err != nil {
+		return err
+	}
+	return tpl.ExecuteTemplate(w, name, data)
+}
+
+// Load if dev or not loaded, and clone for view with
+// built-in url helper bound to its app
+func (t *Template) load(dev bool, view tplView) (*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if dev || t.tpl == nil {
+		if err := t.loadTpls(); err != nil {
+			return nil, err
+		}
+	}
+	if tpl, ok := t.views[view]; ok {
+		return tpl, nil
+	}
+	tpl, err := t.tpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if view.app != nil && tplBuiltins["url"] {
+		tpl.Funcs(template.FuncMap{"url": view.url})
 	}
-	return t.tpl.ExecuteTemplate(w, name, data)
+	t.views[view] = tpl
+	return tpl, nil
 }
 
 //
@@ -144,7 +246,8 @@ type tplRender struct {
 // @impl Render.Html
 func (r *tplRender) Html(status int, name string, data interface{}) error {
 	buf := new(bytes.Buffer)
-	if err := r.tpl.Execute(buf, name, data); err != nil {
+	view := tplView{r.c.app, r.c.prefix}
+	if err := r.tpl.execute(r.c.Options().Development, view, buf, name, data); err != nil {
 		return err
 	}
 	r.c.Res.Html(status, buf.Bytes())
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 )
 
 //
@@ -54,13 +55,25 @@ func (res *Response) End(req *Request) error {
 		if ct := res.Header().Get("Content-Type"); len(ct) == 0 {
 			res.Header().Set("Content-Type", http.DetectContentType(res.Body))
 		}
-	} else {
+	} else if res.Status/100 != 3 && req.Method != "HEAD" { // keep redirect, not modified and HEAD
 		res.Status = 204
 		res.Header().Del("Content-Type")
 		res.Header().Del("Content-Length")
 		res.Header().Del("Content-Encoding")
 	}
 
+	// HEAD only sends headers, with length of body
+	if req.Method == "HEAD" {
+		if len(res.Body) > 0 && len(res.Header().Get("Content-Length")) == 0 {
+			res.Header().Set("Content-Length", strconv.Itoa(len(res.Body)))
+		}
+		res.WriteHeader(res.Status)
+		if res.Close != nil {
+			res.Close()
+		}
+		return nil
+	}
+
 	// write body
 	res.WriteHeader(res.Status)
 	if _, err := res.Write(res.Body); err != nil {
@@ -0,0 +1,152 @@
+package uweb
+
+import (
+	"fmt"
+	"strings"
+)
+
+//
+// Resource actions, ctrl implements any of them
+//
+type (
+	Indexer   interface{ Index(c *Context) }
+	Shower    interface{ Show(c *Context) }
+	Newer     interface{ New(c *Context) }
+	Creator   interface{ Create(c *Context) }
+	Editor    interface{ Edit(c *Context) }
+	Updater   interface{ Update(c *Context) }
+	Destroyer interface{ Destroy(c *Context) }
+)
+
+// Resource of default router
+func Resource(p string, ctrl interface{}) *RResource {
+	return defaultRouter.Resource(p, ctrl)
+}
+
+//
+// Restful resource, routes of "/users" are:
+//
+//	GET       /users           Index    users.index
+//	GET       /users/new       New      users.new
+//	POST      /users           Create   users.create
+//	GET       /users/:id       Show     users.show
+//	GET       /users/:id/edit  Edit     users.edit
+//	PUT PATCH /users/:id       Update   users.update
+//	DELETE    /users/:id       Destroy  users.destroy
+//
+type RResource struct {
+	r    *Router
+	mws  []Middleware // group middlewares
+	path string       // full path, such as "/users/:user_id/posts"
+	name string       // route name prefix, such as "users.posts"
+	base string       // last segment, such as "posts"
+}
+
+// Add resource routes for actions ctrl implements
+func (r *Router) Resource(p string, ctrl interface{}) *RResource {
+	return r.resource(nil, cleanPrefix(p), resourceName(p), ctrl)
+}
+
+// Add resource routes under group prefix, with group
+// middlewares
+func (g *RGroup) Resource(p string, ctrl interface{}) *RResource {
+	return g.r.resource(g.mws, g.prefix+cleanPrefix(p), resourceName(p), ctrl)
+}
+
+// Add nested resource, such as "/users/:user_id/posts"
+// named "users.posts.index"
+func (rs *RResource) Resource(p string, ctrl interface{}) *RResource {
+	path := rs.path + "/:" + singular(rs.base) + "_id" + cleanPrefix(p)
+	return rs.r.resource(rs.mws, path, rs.name+"."+resourceName(p), ctrl)
+}
+
+// Add routes of resource
+func (r *Router) resource(mws []Middleware, path, name string, ctrl interface{}) *RResource {
+	if len(path) == 0 || len(name) == 0 {
+		panic("Resource: invalid path " + path)
+	}
+	rs := &RResource{
+		r:    r,
+		mws:  mws,
+		path: path,
+		name: name,
+		base: name[strings.LastIndexByte(name, '.')+1:],
+	}
+
+	// collection
+	n := 0
+	if v, ok := ctrl.(Indexer); ok {
+		rs.add("GET", path, ctrl, "Index", v.Index).Name(name + ".index")
+		n++
+	}
+	if v, ok := ctrl.(Newer); ok {
+		rs.add("GET", path+"/new", ctrl, "New", v.New).Name(name + ".new")
+		n++
+	}
+	if v, ok := ctrl.(Creator); ok {
+		rs.add("POST", path, ctrl, "Create", v.Create).Name(name + ".create")
+		n++
+	}
+
+	// member
+	member := path + "/:id"
+	if v, ok := ctrl.(Shower); ok {
+		rs.add("GET", member, ctrl, "Show", v.Show).Name(name + ".show")
+		n++
+	}
+	if v, ok := ctrl.(Editor); ok {
+		rs.add("GET", member+"/edit", ctrl, "Edit", v.Edit).Name(name + ".edit")
+		n++
+	}
+	if v, ok := ctrl.(Updater); ok {
+		rs.add("PUT", member, ctrl, "Update", v.Update).Name(name + ".update")
+		rs.add("PATCH", member, ctrl, "Update", v.Update)
+		n++
+	}
+	if v, ok := ctrl.(Destroyer); ok {
+		rs.add("DELETE", member, ctrl, "Destroy", v.Destroy).Name(name + ".destroy")
+		n++
+	}
+
+	// ctrl may be nil if only parent of nested resources
+	if n == 0 && ctrl != nil {
+		panic("Resource: no action of " + path)
+	}
+	return rs
+}
+
+// add action route, handler named as "(*ctrl.User).Index"
+// for listing
+func (rs *RResource) add(method, p string, ctrl interface{}, action string, h HttpHandler) *Route {
+	leaf := newLeaf(p, h, rs.mws)
+	typ := fmt.Sprintf("%T", ctrl)
+	if strings.HasPrefix(typ, "*") {
+		typ = "(" + typ + ")"
+	}
+	leaf.hname = typ + "." + action
+	return rs.r.addLeaf(method, p, leaf)
+}
+
+// "/admin/users" -> "admin.users"
+func resourceName(p string) string {
+	var segs []string
+	for _, s := range strings.Split(strings.Trim(p, "/"), "/") {
+		if len(s) > 0 && s[0] != ':' && s[0] != '*' {
+			segs = append(segs, s)
+		}
+	}
+	return strings.Join(segs, ".")
+}
+
+// "users" -> "user", "categories" -> "category"
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,284 @@
+package uweb
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// envs passed to the restarted child
+const (
+	ENV_LISTENERS  = "UWEB_LISTENERS"
+	ENV_GENERATION = "UWEB_GENERATION"
+)
+
+var (
+	ErrNoFile = errors.New("Application: listener has no file")
+)
+
+// Generation of current process, 0 for the first start
+// and increased by one on each restart
+func Generation() int {
+	gen, _ := strconv.Atoi(os.Getenv(ENV_GENERATION))
+	return gen
+}
+
+//
+// Listener can be passed to the restarted child,
+// key is "network:addr" given by user
+//
+type appListener struct {
+	key string
+	ln  net.Listener
+}
+
+// Listen or take the one inherited from parent, and
+// remember it for restart
+func (a *Application) listen(network, addr string) (net.Listener, error) {
+	key := network + ":" + addr
+	ln, ok := takeInherited(key)
+	if !ok {
+		var err error
+		if ln, err = net.Listen(network, addr); err != nil {
+			return nil, err
+		}
+	}
+	a.keep(key, ln)
+	return ln, nil
+}
+
+// Remember listener for restart, ignore if kept
+func (a *Application) keep(key string, ln net.Listener) {
+	a.mu.Lock()
+	for _, l := range a.lns {
+		if l.ln == ln {
+			a.mu.Unlock()
+			return
+		}
+	}
+	a.lns = append(a.lns, &appListener{key, ln})
+	a.mu.Unlock()
+	register(a)
+}
+
+// Close listener not served and forget it
+func (a *Application) drop(ln net.Listener) {
+	a.mu.Lock()
+	for i, l := range a.lns {
+		if l.ln == ln {
+			a.lns = append(a.lns[:i:i], a.lns[i+1:]...)
+			break
+		}
+	}
+	a.mu.Unlock()
+	ln.Close()
+}
+
+//
+// Apps serving in this process, restart forks one child
+// with listeners of all of them
+//
+var (
+	procMu   sync.Mutex
+	procApps []*Application
+	sigOnce  sync.Once // watch signals once for all apps
+)
+
+// Add app to process, ignore if added
+func register(a *Application) {
+	procMu.Lock()
+	defer procMu.Unlock()
+	for _, app := range procApps {
+		if app == a {
+			return
+		}
+	}
+	procApps = append(procApps, a)
+}
+
+// Remove app from process when shutdown
+func unregister(a *Application) {
+	procMu.Lock()
+	defer procMu.Unlock()
+	for i, app := range procApps {
+		if app == a {
+			procApps = append(procApps[:i:i], procApps[i+1:]...)
+			return
+		}
+	}
+}
+
+// Copy of apps in process
+func registered() []*Application {
+	procMu.Lock()
+	defer procMu.Unlock()
+	return append([]*Application(nil), procApps...)
+}
+
+// Restart re-exec the binary with listeners of all apps
+// in process, then shutdown all of them gracefully and
+// let the child serve. Works on unix only, it is
+// triggered by SIGUSR2.
+func (a *Application) Restart() error {
+	if err := fork(); err != nil {
+		return err
+	}
+	return shutdownAll()
+}
+
+// Start one child with listeners of all apps
+func fork() error {
+	// listeners
+	var lns []*appListener
+	for _, a := range registered() {
+		a.mu.Lock()
+		lns = append(lns, a.lns...)
+		a.mu.Unlock()
+	}
+	if len(lns) == 0 {
+		return ErrNoListener
+	}
+
+	// files are dup, close them after start
+	keys := make([]string, 0, len(lns))
+	files := make([]*os.File, 0, len(lns))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, l := range lns {
+		fl, ok := l.ln.(interface {
+			File() (*os.File, error)
+		})
+		if !ok {
+			return fmt.Errorf("%w, %s", ErrNoFile, l.key)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		keys = append(keys, l.key)
+		files = append(files, f)
+	}
+
+	// envs
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, ENV_LISTENERS+"=") || strings.HasPrefix(kv, ENV_GENERATION+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env, ENV_LISTENERS+"="+strings.Join(keys, ";"))
+	env = append(env, ENV_GENERATION+"="+strconv.Itoa(Generation()+1))
+
+	// start
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+	cmd.ExtraFiles = files // fd from 3
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	log.Println(LOG_TAG, "Application: restarted, child pid", cmd.Process.Pid)
+
+	// socket files now belong to child
+	for _, l := range lns {
+		if ul, ok := l.ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return cmd.Process.Release()
+}
+
+//
+// Listeners inherited from parent
+//
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	inherited   map[string]net.Listener
+)
+
+// Get listener passed by parent after restart, for those
+// listen by themselves and call Serve, such as:
+//
+//	ln, ok := uweb.InheritedListener("tcp", "127.0.0.1:8080")
+//	if !ok {
+//		ln, err = net.Listen("tcp", "127.0.0.1:8080")
+//	}
+//	app.Serve(ln)
+//
+// addr is the one given to Listen, or ln.Addr() of the
+// listener passed to Serve in parent. Each is taken once.
+func InheritedListener(network, addr string) (net.Listener, bool) {
+	return takeInherited(network + ":" + addr)
+}
+
+// Take inherited listener by key, each only once
+func takeInherited(key string) (net.Listener, bool) {
+	inheritOnce.Do(loadInherited)
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+
+	ln, ok := inherited[key]
+	if ok {
+		delete(inherited, key)
+	}
+	return ln, ok
+}
+
+// Take all inherited listeners with key prefix
+func takeInheritedPrefix(prefix string) []net.Listener {
+	inheritOnce.Do(loadInherited)
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+
+	var lns []net.Listener
+	for key, ln := range inherited {
+		if strings.HasPrefix(key, prefix) {
+			lns = append(lns, ln)
+			delete(inherited, key)
+		}
+	}
+	return lns
+}
+
+// Load listeners passed by parent
+func loadInherited() {
+	inherited = make(map[string]net.Listener)
+
+	v := os.Getenv(ENV_LISTENERS)
+	if len(v) == 0 {
+		return
+	}
+	os.Unsetenv(ENV_LISTENERS)
+
+	for i, key := range strings.Split(v, ";") {
+		fd := SD_LISTEN_FDS_START + i
+		f := os.NewFile(uintptr(fd), key)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			log.Println(LOG_TAG, "Application: inherit listener err", key, err)
+			continue
+		}
+		if DEBUG {
+			log.Println(LOG_TAG, "Application: inherit listener", key)
+		}
+		inherited[key] = ln
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package uweb
+
+import (
+	"os"
+	"syscall"
+)
+
+// signal to restart
+var restartSignal os.Signal = syscall.SIGUSR2
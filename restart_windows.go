@@ -0,0 +1,8 @@
+package uweb
+
+import (
+	"os"
+)
+
+// no restart signal on windows
+var restartSignal os.Signal
@@ -3,57 +3,89 @@ package uweb
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 //
-// export as middleware
+// export default router as middleware, routers created
+// by NewRouter can be used as middlewares too
 //
 func MdRouter() Middleware {
 	return defaultRouter
 }
 
 //
-// Default router
+// Default router, used by Get, Post, etc.
 //
 var (
 	defaultRouter = NewRouter()
 )
 
+// Get default router
+func DefaultRouter() *Router {
+	return defaultRouter
+}
+
 // GET
-func Get(p string, h HttpHandler) {
-	defaultRouter.Get(p, h)
+func Get(p string, h HttpHandler) *Route {
+	return defaultRouter.Get(p, h)
 }
 
 // POST
-func Post(p string, h HttpHandler) {
-	defaultRouter.Post(p, h)
+func Post(p string, h HttpHandler) *Route {
+	return defaultRouter.Post(p, h)
 }
 
 // PUT
-func Put(p string, h HttpHandler) {
-	defaultRouter.Put(p, h)
+func Put(p string, h HttpHandler) *Route {
+	return defaultRouter.Put(p, h)
 }
 
 // PATCH
-func Patch(p string, h HttpHandler) {
-	defaultRouter.Patch(p, h)
+func Patch(p string, h HttpHandler) *Route {
+	return defaultRouter.Patch(p, h)
 }
 
 // DELETE
-func Del(p string, h HttpHandler) {
-	defaultRouter.Del(p, h)
+func Del(p string, h HttpHandler) *Route {
+	return defaultRouter.Del(p, h)
 }
 
 // OPTIONS
-func Opts(p string, h HttpHandler) {
-	defaultRouter.Opts(p, h)
+func Opts(p string, h HttpHandler) *Route {
+	return defaultRouter.Opts(p, h)
 }
 
 // HEAD
-func Head(p string, h HttpHandler) {
-	defaultRouter.Head(p, h)
+func Head(p string, h HttpHandler) *Route {
+	return defaultRouter.Head(p, h)
+}
+
+// Any method, such as "PROPFIND"
+func Add(method, p string, h HttpHandler) *Route {
+	return defaultRouter.Add(method, p, h)
+}
+
+// All standard methods
+func Any(p string, h HttpHandler) *Route {
+	return defaultRouter.Any(p, h)
+}
+
+// Methods in list
+func Match(methods []string, p string, h HttpHandler) *Route {
+	return defaultRouter.Match(methods, p, h)
+}
+
+// Group
+func Group(prefix string, mws ...Middleware) *RGroup {
+	return defaultRouter.Group(prefix, mws...)
 }
 
 //
@@ -61,14 +93,101 @@ func Head(p string, h HttpHandler) {
 //
 type HttpHandler func(c *Context)
 
+func (h HttpHandler) Name() string {
+	return "handler"
+}
+
+// Run handler as the last one of group middlewares
+// @impl Middleware
+func (h HttpHandler) Handle(c *Context) int {
+	h(c)
+	return NEXT_CONTINUE
+}
+
 //
 // Tree node
 //
 type RNode struct {
-	child   []*RNode    // children
-	height  int         // tree height, for fast match
-	pattern string      // path pattern
-	handler HttpHandler // only last height has h
+	child   []*RNode       // children
+	height  int            // tree height, for fast match
+	wild    bool           // has wildcard in subtree
+	pattern string         // path pattern, "name", ":name" or "*name"
+	name    string         // param name
+	re      *regexp.Regexp // param constraint
+	shape   string         // pattern without param name
+	handler HttpHandler    // only last height has h
+	chain   []Middleware   // group middlewares and handler
+	path    string         // full pattern of handler
+	source  string         // file:line where handler added
+	hname   string         // handler name if not func name, for listing
+}
+
+//
+// Param types used as ":name<type>"
+//
+var paramTypes = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"hex":   `[0-9a-fA-F]+`,
+	"slug":  `[a-z0-9]+(-[a-z0-9]+)*`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// Register param type, need call before routes added
+func ParamType(name, expr string) {
+	if _, ok := paramTypes[name]; ok {
+		panic("RNode: DUP param type")
+	}
+	paramTypes[name] = expr
+}
+
+// Create node, param pattern may have constraint,
+// ":id(\d+)" by regexp or ":id<int>" by type. Regexp
+// should not contain "/".
+func newRNode(pattern string) (*RNode, error) {
+	n := &RNode{
+		pattern: pattern,
+		shape:   pattern,
+	}
+	if !n.isParam() && !n.isWild() {
+		return n, nil
+	}
+
+	// name
+	n.name = pattern[1:]
+	n.shape = pattern[:1]
+	i := strings.IndexAny(n.name, "(<")
+	if i == -1 {
+		return n, nil
+	}
+	if !n.isParam() {
+		return nil, ErrBadPattern
+	}
+	expr := n.name[i:]
+	n.name = n.name[:i]
+
+	// constraint
+	switch {
+	case expr[0] == '(' && expr[len(expr)-1] == ')':
+		expr = expr[1 : len(expr)-1]
+	case expr[0] == '<' && expr[len(expr)-1] == '>':
+		t, ok := paramTypes[expr[1:len(expr)-1]]
+		if !ok {
+			return nil, ErrBadPattern
+		}
+		expr = t
+	default:
+		return nil, ErrBadPattern
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, err
+	}
+	n.re = re
+	n.shape = ":" + expr
+	return n, nil
 }
 
 // Dump internal status
@@ -77,7 +196,7 @@ func (n *RNode) Dump(indent string) {
 	if len(indent) == 0 {
 		indent = " "
 	}
-	fmt.Printf("%s pattern:%s, height:%d, handler:%d, child:%d\n", indent+indent, n.pattern, n.height, n.handler, len(n.child))
+	fmt.Printf("%s pattern:%s, height:%d, handler:%s, child:%d\n", indent+indent, n.pattern, n.height, funcName(n.handler), len(n.child))
 
 	// dump child
 	for _, c := range n.child {
@@ -85,10 +204,25 @@ func (n *RNode) Dump(indent string) {
 	}
 }
 
-// Add child node
-func (n *RNode) Add(ps []string, handler HttpHandler) (int, error) {
+// Add child node, mws run before handler
+func (n *RNode) Add(ps []string, handler HttpHandler, mws ...Middleware) (int, error) {
+	leaf := newLeaf("/"+strings.Join(ps, "/"), handler, mws)
+	return n.add(ps, leaf)
+}
+
+// Add child node with handler and info in leaf,
+// report conflict if the same shape path exists
+func (n *RNode) add(ps []string, leaf *RNode) (int, error) {
+	if old := n.find(ps); old != nil {
+		return 0, &ConflictError{
+			Path:      leaf.path,
+			Source:    leaf.source,
+			OldPath:   old.path,
+			OldSource: old.source,
+		}
+	}
 	ps = append([]string{n.pattern}, ps...)
-	if ok, err := n.merge(ps, handler); err != nil {
+	if ok, err := n.merge(ps, leaf); err != nil {
 		return 0, err
 	} else if ok {
 		n.calc()
@@ -96,12 +230,96 @@ func (n *RNode) Add(ps []string, handler HttpHandler) (int, error) {
 	return n.height, nil
 }
 
+// Create leaf info for handler
+func newLeaf(p string, handler HttpHandler, mws []Middleware) *RNode {
+	var chain []Middleware
+	if len(mws) > 0 {
+		chain = append(append(chain, mws...), handler)
+	}
+	return &RNode{
+		handler: handler,
+		chain:   chain,
+		path:    p,
+		source:  callerSource(),
+	}
+}
+
+// Copy leaf info
+func (n *RNode) setLeaf(leaf *RNode) {
+	n.handler = leaf.handler
+	n.chain = leaf.chain
+	n.path = leaf.path
+	n.source = leaf.source
+	n.hname = leaf.hname
+}
+
+// Find handler node with the same shape path, such as
+// "/users/:id" and "/users/:uid"
+func (n *RNode) find(ps []string) *RNode {
+	if len(ps) == 0 {
+		if n.handler != nil {
+			return n
+		}
+		return nil
+	}
+	p, err := newRNode(ps[0])
+	if err != nil {
+		return nil
+	}
+	for _, c := range n.child {
+		if c.shape != p.shape {
+			continue
+		}
+		if f := c.find(ps[1:]); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
 var (
-	ErrDupPath = errors.New("RNode: dup path")
+	ErrDupPath     = errors.New("RNode: dup path")
+	ErrWildNotLast = errors.New("RNode: wildcard should be the last")
+	ErrBadPattern  = errors.New("RNode: bad pattern")
 )
 
+//
+// Route conflict error, with both sources
+//
+type ConflictError struct {
+	Method    string
+	Path      string
+	Source    string
+	OldPath   string
+	OldSource string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Router: %s %s at %s conflicts with %s at %s",
+		e.Method, e.Path, e.Source, e.OldPath, e.OldSource)
+}
+
+// package path prefix of functions
+var pkgPrefix = reflect.TypeOf(RNode{}).PkgPath() + "."
+
+// file:line of the first caller outside this package
+func callerSource() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
 // Merge path to node
-func (n *RNode) merge(ps []string, handler HttpHandler) (bool, error) {
+func (n *RNode) merge(ps []string, leaf *RNode) (bool, error) {
 	// check ps
 	if len(ps) == 0 {
 		return false, nil
@@ -112,10 +330,10 @@ func (n *RNode) merge(ps []string, handler HttpHandler) (bool, error) {
 		return false, nil
 	}
 	if len(ps) == 1 {
-		if n.height == 1 || n.handler != nil {
+		if n.handler != nil {
 			return false, ErrDupPath
 		}
-		n.handler = handler
+		n.setLeaf(leaf)
 		return true, nil
 	}
 
@@ -123,7 +341,7 @@ func (n *RNode) merge(ps []string, handler HttpHandler) (bool, error) {
 	ps = ps[1:]
 	merged := false
 	for _, c := range n.child {
-		if ok, err := c.merge(ps, handler); err != nil {
+		if ok, err := c.merge(ps, leaf); err != nil {
 			return false, err
 		} else if ok {
 			merged = true
@@ -135,65 +353,136 @@ func (n *RNode) merge(ps []string, handler HttpHandler) (bool, error) {
 	if !merged {
 		nodes := make([]*RNode, len(ps))
 		for i, p := range ps {
-			nodes[i] = &RNode{
-				pattern: p,
-			}
 			if len(p) == 0 {
-				nodes[i].Dump("")
 				panic("pattern should not empty")
 			}
+			node, err := newRNode(p)
+			if err != nil {
+				return false, err
+			}
+			nodes[i] = node
 			if i > 0 {
 				parent := nodes[i-1]
 				parent.child = append(parent.child, nodes[i])
 			}
 		}
-		nodes[len(nodes)-1].handler = handler // only last node owns handler
-		n.child = append(n.child, nodes[0])
+		nodes[len(nodes)-1].setLeaf(leaf) // only last node owns handler
+		n.addChild(nodes[0])
 	}
 
 	// ok
 	return true, nil
 }
 
-// Calc calcuate height of every node
+// Priority of node in siblings, lower is tried first
+func (n *RNode) rank() int {
+	switch {
+	case n.isWild():
+		return 3
+	case n.isParam() && n.re == nil:
+		return 2
+	case n.isParam():
+		return 1
+	}
+	return 0
+}
+
+// Add child keep siblings in order: literals, constrained
+// params, params and wildcards, so that the priority not
+// depends on the order of adding
+func (n *RNode) addChild(c *RNode) {
+	i := len(n.child)
+	for i > 0 && n.child[i-1].rank() > c.rank() {
+		i--
+	}
+	n.child = append(n.child, nil)
+	copy(n.child[i+1:], n.child[i:])
+	n.child[i] = c
+}
+
+// Calc calcuate height of every node, and mark
+// nodes with wildcard in subtree
 func (n *RNode) calc() int {
 	max := 0
+	n.wild = n.isWild()
 	for _, c := range n.child {
 		h := c.calc()
 		if max < h {
 			max = h
 		}
+		if c.wild {
+			n.wild = true
+		}
 	}
 	n.height = max + 1
 	return n.height
 }
 
-// Match patten with path array, and return matched pattern
+// Pattern is "*name", which matches the rest of path
+func (n *RNode) isWild() bool {
+	return n.pattern[0] == '*'
+}
+
+// Pattern is ":name"
+func (n *RNode) isParam() bool {
+	return n.pattern[0] == ':'
+}
+
+// Params key, "*" for unnamed wildcard
+func (n *RNode) key() string {
+	if len(n.name) == 0 {
+		return "*"
+	}
+	return n.name
+}
+
+// Match patten with path array, and return matched node
 func (n *RNode) Match(ps []string, ms map[string]string) *RNode {
-	// if height not equal, ignore
+	// if path longer than tree, ignore
 	s := len(ps)
-	if n.height < s {
+	if s == 0 {
 		return nil
 	}
-	if s == 0 {
+	if !n.wild && n.height < s {
 		return nil
 	}
 
-	// if pattern match fail, ignore
+	// wildcard captures the rest, including slashes
 	p0 := ps[0]
-	if n.pattern[0:1] != ":" && n.pattern != p0 {
+	if n.isWild() {
+		if n.handler == nil {
+			return nil
+		}
+		ms[n.key()] = strings.Join(ps, "/")
+		return n
+	}
+
+	// if pattern match fail, ignore
+	if n.isParam() {
+		if n.re != nil && !n.re.MatchString(p0) {
+			return nil
+		}
+	} else if n.pattern != p0 {
 		return nil
 	}
 
 	// if current node matched
-	if len(ps) == 1 {
-		if n.height == 1 {
-			if n.pattern[0:1] == ":" {
-				ms[n.pattern[1:]] = p0
+	if s == 1 {
+		if n.handler != nil {
+			if n.isParam() {
+				ms[n.key()] = p0
 			}
 			return n
-		} else if n.pattern == p0 {
-			return n
+		}
+		// wildcard child matches empty rest
+		for _, c := range n.child {
+			if c.isWild() && c.handler != nil {
+				if n.isParam() {
+					ms[n.key()] = p0
+				}
+				ms[c.key()] = ""
+				return c
+			}
 		}
 		return nil
 	}
@@ -201,8 +490,8 @@ func (n *RNode) Match(ps []string, ms map[string]string) *RNode {
 	// match child first
 	for _, c := range n.child {
 		if h := c.Match(ps[1:], ms); h != nil {
-			if n.pattern[0:1] == ":" {
-				ms[n.pattern[1:]] = p0
+			if n.isParam() {
+				ms[n.key()] = p0
 			}
 			return h
 		}
@@ -213,11 +502,13 @@ func (n *RNode) Match(ps []string, ms map[string]string) *RNode {
 }
 
 //
-// RTree is path router tree, for fast match
+// RTree is path router tree, for fast match. Nodes are
+// frozen after changed, and matched without lock.
 //
 type RTree struct {
-	mu   sync.Mutex
-	root *RNode
+	mu     sync.Mutex   // for adding and freezing
+	root   *RNode       // tree for adding
+	frozen atomic.Value // *fnode, nil if changed
 }
 
 // Create a tree with a root node with patten "/"
@@ -230,65 +521,100 @@ func NewRTree() *RTree {
 	}
 }
 
-// convert to path array
+// convert to path array, "/" is empty array
 func (rt *RTree) parsePath(p string) []string {
-	return strings.Split(strings.Trim(p, "/"), "/")
+	p = strings.Trim(p, "/")
+	if len(p) == 0 {
+		return nil
+	}
+	return strings.Split(p, "/")
 }
 
-// Add path to tree
-func (rt *RTree) Add(p string, h HttpHandler) error {
+// Add path to tree, mws run before h
+func (rt *RTree) Add(p string, h HttpHandler, mws ...Middleware) error {
+	return rt.add(p, newLeaf(p, h, mws))
+}
+
+// Add path with leaf info
+func (rt *RTree) add(p string, leaf *RNode) error {
 	ps := rt.parsePath(p)
+	for i, s := range ps {
+		if len(s) > 0 && s[0] == '*' && i != len(ps)-1 {
+			return ErrWildNotLast
+		}
+	}
 
 	rt.mu.Lock()
 	defer rt.mu.Unlock()
 
-	if _, err := rt.root.Add(ps, h); err != nil {
+	if _, err := rt.root.add(ps, leaf); err != nil {
 		return err
 	}
+	rt.frozen.Store((*fnode)(nil)) // freeze again when match
 	return nil
 }
 
 // Match path and get handler
 func (rt *RTree) Match(p string) (map[string]string, HttpHandler) {
-	ps := append([]string{"/"}, rt.parsePath(p)...)
-	ms := make(map[string]string)
-
-	rt.mu.Lock()
-	defer rt.mu.Unlock()
-
-	if n := rt.root.Match(ps, ms); n != nil {
-		return ms, n.handler
+	var ps []param
+	n := rt.lookup(p, &ps)
+	if n == nil {
+		return nil, nil
+	}
+	ms := make(map[string]string, len(ps))
+	for _, m := range ps {
+		ms[m.key] = m.value
 	}
-	return nil, nil
+	return ms, n.handler
 }
 
 //
 // Router is a restfull path router
 //
 type Router struct {
-	gets  *RTree
-	puts  *RTree
-	patchs  *RTree
-	posts *RTree
-	dels  *RTree
-	opts  *RTree
-	heads *RTree
+	gets   *RTree
+	puts   *RTree
+	patchs *RTree
+	posts  *RTree
+	dels   *RTree
+	opts   *RTree
+	heads  *RTree
+	more   atomic.Value // map[string]*RTree, other methods, copy on write
+
+	// redirect to route pattern if path only differs in
+	// trailing slash, such as "/users/" to "/users"
+	RedirectSlash bool
+
+	// match path case-insensitively, and redirect to route
+	// pattern, such as "/Users/42" to "/users/42"
+	RedirectCase bool
+
+	// named routes
+	mu    sync.RWMutex
+	names map[string]*Route
 }
 
-// Create default router
+// Create router, which has its own routes, and is used
+// by app.Use, such as:
+//
+//	api := uweb.NewRouter()
+//	api.Get("/users/:id", showUser)
+//	app.Use(api)
+//
 func NewRouter() *Router {
 	return &Router{
-		gets:  NewRTree(),
-		puts:  NewRTree(),
-		patchs:  NewRTree(),
-		posts: NewRTree(),
-		dels:  NewRTree(),
-		opts:  NewRTree(),
-		heads: NewRTree(),
+		gets:   NewRTree(),
+		puts:   NewRTree(),
+		patchs: NewRTree(),
+		posts:  NewRTree(),
+		dels:   NewRTree(),
+		opts:   NewRTree(),
+		heads:  NewRTree(),
+		names:  make(map[string]*Route),
 	}
 }
 
-// get route tree
+// get route tree, nil if no route of method
 func (r *Router) treeByMethod(method string) *RTree {
 	var t *RTree
 	switch method {
@@ -306,80 +632,403 @@ func (r *Router) treeByMethod(method string) *RTree {
 		t = r.opts
 	case "HEAD":
 		t = r.heads
+	default:
+		more, _ := r.more.Load().(map[string]*RTree)
+		t = more[method]
 	}
 	return t
 }
 
+// get route tree, create one if method is not standard
+func (r *Router) methodTree(method string) *RTree {
+	if t := r.treeByMethod(method); t != nil {
+		return t
+	}
+	if !validMethod(method) {
+		panic("Router: invalid method " + method)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, _ := r.more.Load().(map[string]*RTree)
+	if t, ok := old[method]; ok {
+		return t
+	}
+	more := make(map[string]*RTree, len(old)+1)
+	for m, t := range old {
+		more[m] = t
+	}
+	t := NewRTree()
+	more[method] = t
+	r.more.Store(more)
+	return t
+}
+
+// Standard methods, and others sorted
+func (r *Router) methods() []string {
+	more, _ := r.more.Load().(map[string]*RTree)
+	others := make([]string, 0, len(more))
+	for m := range more {
+		others = append(others, m)
+	}
+	sort.Strings(others)
+	return append(append([]string(nil), routeMethods...), others...)
+}
+
+// Method is a token, such as "PROPFIND"
+func validMethod(method string) bool {
+	if len(method) == 0 {
+		return false
+	}
+	for _, c := range method {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 var (
-	ErrRouteNotFound = errors.New("Router: not found")
+	ErrRouteNotFound    = errors.New("Router: not found")
+	ErrMethodNotAllowed = errors.New("Router: method not allowed")
+	ErrMethodNotSupport = errors.New("Router: method not support yet")
 )
 
+// methods in Allow header order
+var routeMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
 func (r *Router) Name() string {
 	return "route"
 }
 
 // Middleware impl
 func (r *Router) Handle(c *Context) int {
-	// t
-	t := r.treeByMethod(c.Req.Method)
-	if t == nil {
-		c.Res.Status = 501
-		c.Res.Err = errors.New("Router: method not support yet")
+	// match, params buffer is reused by context,
+	// host params first so path params win
+	p, raw := routePath(c.Req.URL)
+	base := len(c.hps)
+	ps := append(c.pbuf[:0], c.hps...)
+	n, known := r.match(c.Req.Method, p, &ps, false)
+	fold := false
+	if n == nil && r.RedirectCase {
+		ps = ps[:base]
+		n, _ = r.match(c.Req.Method, p, &ps, true)
+		fold = n != nil
+	}
+	c.pbuf = ps
+	if n == nil {
+		return r.notFound(c, p, known)
+	}
+	if raw {
+		unescapeParams(ps[base:])
+	}
+	c.Req.Params = c.params(ps)
+
+	// redirect to canonical path
+	if fold || (r.RedirectSlash && n.kind != kindWild && hasSlash(p) != hasSlash(n.path)) {
+		return r.redirect(c, n)
+	}
+
+	// handle, run group middlewares if any
+	if len(n.chain) > 0 {
+		return c.run(n.chain)
+	}
+	n.handler(c)
+	return NEXT_CONTINUE
+}
+
+// Match path by method tree, HEAD falls back to GET, and
+// known is false if no route of method
+func (r *Router) match(method, p string, ps *[]param, fold bool) (n *fnode, known bool) {
+	base := len(*ps)
+	lookup := (*RTree).lookup
+	if fold {
+		lookup = (*RTree).lookupFold
+	}
+	t := r.treeByMethod(method)
+	if t != nil {
+		n = lookup(t, p, ps)
+	}
+	if n == nil && method == "HEAD" {
+		*ps = (*ps)[:base]
+		n = lookup(r.gets, p, ps) // Response.End will drop body
+	}
+	return n, t != nil
+}
+
+// Path to match, which is URL.Path, or URL.RawPath with
+// segments decoded except "%2F" and "%25" if it has
+// encoded slash, so "/files/a%2Fb" matches "/files/:name"
+func routePath(u *url.URL) (string, bool) {
+	if len(u.RawPath) == 0 {
+		return u.Path, false
+	}
+	ep := u.EscapedPath()
+	if !strings.Contains(ep, "%2F") && !strings.Contains(ep, "%2f") {
+		return u.Path, false
+	}
+	segs := strings.Split(ep, "/")
+	for i, seg := range segs {
+		s, err := url.PathUnescape(seg)
+		if err != nil {
+			return u.Path, false
+		}
+		segs[i] = pathEscaper.Replace(s)
+	}
+	return strings.Join(segs, "/"), true
+}
+
+var (
+	pathEscaper   = strings.NewReplacer("%", "%25", "/", "%2F")
+	pathUnescaper = strings.NewReplacer("%25", "%", "%2F", "/")
+)
+
+// Decode params matched from raw path
+func unescapeParams(ps []param) {
+	for i := range ps {
+		ps[i].value = pathUnescaper.Replace(ps[i].value)
+	}
+}
+
+// Path ends with "/", except root
+func hasSlash(p string) bool {
+	return len(p) > 1 && p[len(p)-1] == '/'
+}
+
+// Redirect to path filled by route pattern and params,
+// keep method by 308 if not GET or HEAD
+func (r *Router) redirect(c *Context, n *fnode) int {
+	params := make(map[string]string, len(c.Req.Params))
+	for k, v := range c.Req.Params {
+		params[k] = v
+	}
+	u, err := fillPath(n.path, n.path, params)
+	if err != nil {
+		c.Res.Status = 500
+		c.Res.Err = err
 		return NEXT_BREAK
 	}
+	u = c.prefix + u
+	if len(c.Req.URL.RawQuery) > 0 {
+		u += "?" + c.Req.URL.RawQuery
+	}
+
+	c.Res.Header().Set("Location", u)
+	c.Res.Status = 301
+	if c.Req.Method != "GET" && c.Req.Method != "HEAD" {
+		c.Res.Status = 308
+	}
+	return NEXT_BREAK
+}
 
-	// then match
-	p, h := t.Match(c.Req.URL.Path)
-	if h == nil {
+// Path not matched by method, answer OPTIONS or 405 with
+// Allow header if matched by other methods
+func (r *Router) notFound(c *Context, p string, known bool) int {
+	allow := r.allowed(p)
+	switch {
+	case len(allow) > 0 && c.Req.Method == "OPTIONS":
+		c.Res.Header().Set("Allow", strings.Join(allow, ", "))
+		c.Res.Status = 204
+	case len(allow) > 0:
+		c.Res.Header().Set("Allow", strings.Join(allow, ", "))
+		c.Res.Status = 405
+		c.Res.Err = ErrMethodNotAllowed
+	case !known:
+		c.Res.Status = 501
+		c.Res.Err = ErrMethodNotSupport
+	default:
 		c.Res.Status = 404
 		c.Res.Err = ErrRouteNotFound
-		return NEXT_BREAK
 	}
+	return NEXT_BREAK
+}
 
-	// handle
-	c.Req.Params = p
-	h(c)
-	return NEXT_CONTINUE
+// Methods which path matched, with OPTIONS, and HEAD if GET
+func (r *Router) allowed(p string) []string {
+	var allow []string
+	get := false
+	for _, m := range r.methods() {
+		if m == "OPTIONS" {
+			continue
+		}
+		if m == "HEAD" && get {
+			allow = append(allow, m)
+			continue
+		}
+		var ps []param
+		if n := r.treeByMethod(m).lookup(p, &ps); n != nil {
+			allow = append(allow, m)
+			get = m == "GET"
+		}
+	}
+	if len(allow) > 0 {
+		allow = append(allow, "OPTIONS")
+	}
+	return allow
 }
 
-// add handler to method trees
-func (r *Router) addHandler(method, p string, h HttpHandler) {
+// add handler to method trees, mws run before h
+func (r *Router) addHandler(method, p string, h HttpHandler, mws ...Middleware) *Route {
+	return r.addLeaf(method, p, newLeaf(p, h, mws))
+}
+
+// add leaf to method tree
+func (r *Router) addLeaf(method, p string, leaf *RNode) *Route {
 	// t
-	t := r.treeByMethod(method)
-	if t == nil {
-		panic("Router: method not support yet")
-	}
+	t := r.methodTree(method)
 
-	// add
-	if err := t.Add(p, h); err != nil {
+	// add, conflict should be fixed before start
+	if err := t.add(p, leaf); err != nil {
+		if ce, ok := err.(*ConflictError); ok {
+			ce.Method = method
+		}
 		panic(err)
 	}
+	return &Route{
+		r:      r,
+		method: method,
+		path:   p,
+	}
+}
+
+func (r *Router) Get(p string, h HttpHandler) *Route {
+	return r.addHandler("GET", p, h)
+}
+
+func (r *Router) Post(p string, h HttpHandler) *Route {
+	return r.addHandler("POST", p, h)
+}
+
+func (r *Router) Put(p string, h HttpHandler) *Route {
+	return r.addHandler("PUT", p, h)
+}
+
+func (r *Router) Patch(p string, h HttpHandler) *Route {
+	return r.addHandler("PATCH", p, h)
+}
+
+func (r *Router) Del(p string, h HttpHandler) *Route {
+	return r.addHandler("DELETE", p, h)
+}
+
+func (r *Router) Opts(p string, h HttpHandler) *Route {
+	return r.addHandler("OPTIONS", p, h)
+}
+
+func (r *Router) Head(p string, h HttpHandler) *Route {
+	return r.addHandler("HEAD", p, h)
+}
+
+// Add route of any method, such as "PROPFIND"
+func (r *Router) Add(method, p string, h HttpHandler) *Route {
+	return r.addHandler(method, p, h)
+}
+
+// Add route of all standard methods, returns GET route
+func (r *Router) Any(p string, h HttpHandler) *Route {
+	return r.Match(routeMethods, p, h)
+}
+
+// Add route of methods, returns the first route
+func (r *Router) Match(methods []string, p string, h HttpHandler) *Route {
+	return r.addMethods(methods, p, h)
+}
+
+// add handler to trees of methods
+func (r *Router) addMethods(methods []string, p string, h HttpHandler, mws ...Middleware) *Route {
+	if len(methods) == 0 {
+		panic("Router: no method of " + p)
+	}
+	var first *Route
+	for _, m := range methods {
+		rt := r.addHandler(m, p, h, mws...)
+		if first == nil {
+			first = rt
+		}
+	}
+	return first
+}
+
+// Create route group with shared prefix and middlewares
+func (r *Router) Group(prefix string, mws ...Middleware) *RGroup {
+	return &RGroup{
+		r:      r,
+		prefix: cleanPrefix(prefix),
+		mws:    mws,
+	}
+}
+
+//
+// Route group, middlewares only run for routes in group,
+// after matched and before handler
+//
+type RGroup struct {
+	r      *Router
+	prefix string       // path prefix
+	mws    []Middleware // group middlewares
+}
+
+// "/api/v2/" -> "/api/v2"
+func cleanPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) == 0 {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// Create nested group, parent's middlewares run first
+func (g *RGroup) Group(prefix string, mws ...Middleware) *RGroup {
+	all := make([]Middleware, 0, len(g.mws)+len(mws))
+	all = append(all, g.mws...)
+	all = append(all, mws...)
+	return &RGroup{
+		r:      g.r,
+		prefix: g.prefix + cleanPrefix(prefix),
+		mws:    all,
+	}
+}
+
+func (g *RGroup) Get(p string, h HttpHandler) *Route {
+	return g.r.addHandler("GET", g.prefix+p, h, g.mws...)
+}
+
+func (g *RGroup) Post(p string, h HttpHandler) *Route {
+	return g.r.addHandler("POST", g.prefix+p, h, g.mws...)
+}
+
+func (g *RGroup) Put(p string, h HttpHandler) *Route {
+	return g.r.addHandler("PUT", g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Get(p string, h HttpHandler) {
-	r.addHandler("GET", p, h)
+func (g *RGroup) Patch(p string, h HttpHandler) *Route {
+	return g.r.addHandler("PATCH", g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Post(p string, h HttpHandler) {
-	r.addHandler("POST", p, h)
+func (g *RGroup) Del(p string, h HttpHandler) *Route {
+	return g.r.addHandler("DELETE", g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Put(p string, h HttpHandler) {
-	r.addHandler("PUT", p, h)
+func (g *RGroup) Opts(p string, h HttpHandler) *Route {
+	return g.r.addHandler("OPTIONS", g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Patch(p string, h HttpHandler) {
-	r.addHandler("PATCH", p, h)
+func (g *RGroup) Head(p string, h HttpHandler) *Route {
+	return g.r.addHandler("HEAD", g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Del(p string, h HttpHandler) {
-	r.addHandler("DELETE", p, h)
+func (g *RGroup) Add(method, p string, h HttpHandler) *Route {
+	return g.r.addHandler(method, g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Opts(p string, h HttpHandler) {
-	r.addHandler("OPTIONS", p, h)
+func (g *RGroup) Any(p string, h HttpHandler) *Route {
+	return g.r.addMethods(routeMethods, g.prefix+p, h, g.mws...)
 }
 
-func (r *Router) Head(p string, h HttpHandler) {
-	r.addHandler("HEAD", p, h)
+func (g *RGroup) Match(methods []string, p string, h HttpHandler) *Route {
+	return g.r.addMethods(methods, g.prefix+p, h, g.mws...)
 }
@@ -0,0 +1,111 @@
+package uweb
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// routes for benchmark
+func benchRouter() *Router {
+	r := NewRouter()
+	h := func(c *Context) {}
+	for _, p := range []string{
+		"/",
+		"/about",
+		"/contact",
+		"/users",
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id/posts/:pid",
+		"/api/v2/orders/new",
+		"/api/v2/orders/:id<int>",
+		"/files/*path",
+		"/a/b/c/d/e/f/g",
+	} {
+		r.Get(p, h)
+	}
+	return r
+}
+
+// context for benchmark
+func benchContext(method, path string) *Context {
+	c := NewContext(NewApp())
+	c.Req = NewRequest(httptest.NewRequest(method, path, nil))
+	c.Res = NewResponse(httptest.NewRecorder())
+	return c
+}
+
+// Match by Router middleware, which uses frozen tree
+func benchRouter1(b *testing.B, path string) {
+	r := benchRouter()
+	c := benchContext("GET", path)
+	r.Handle(c) // freeze
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Handle(c)
+	}
+}
+
+// Match node by node with lock and map, the way before
+// tree frozen
+func benchRouterNode(b *testing.B, path string) {
+	r := benchRouter()
+	c := benchContext("GET", path)
+	t := r.gets
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps := append([]string{"/"}, t.parsePath(path)...)
+		ms := make(map[string]string)
+		t.mu.Lock()
+		n := t.root.Match(ps, ms)
+		t.mu.Unlock()
+		if n != nil {
+			c.Req.Params = ms
+			n.handler(c)
+		}
+	}
+}
+
+func BenchmarkRouterParam(b *testing.B)     { benchRouter1(b, "/users/42/posts/7") }
+func BenchmarkRouterNodeParam(b *testing.B) { benchRouterNode(b, "/users/42/posts/7") }
+func BenchmarkRouterLong(b *testing.B)      { benchRouter1(b, "/a/b/c/d/e/f/g") }
+func BenchmarkRouterNodeLong(b *testing.B)  { benchRouterNode(b, "/a/b/c/d/e/f/g") }
+func BenchmarkRouterWild(b *testing.B)      { benchRouter1(b, "/files/css/site/main.css") }
+func BenchmarkRouterNodeWild(b *testing.B)  { benchRouterNode(b, "/files/css/site/main.css") }
+
+// Parallel match, frozen tree has no lock contention
+func BenchmarkRouterParallel(b *testing.B) {
+	r := benchRouter()
+	r.Handle(benchContext("GET", "/")) // freeze
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		c := benchContext("GET", "/api/v2/orders/42")
+		for pb.Next() {
+			r.Handle(c)
+		}
+	})
+}
+
+func BenchmarkRouterNodeParallel(b *testing.B) {
+	r := benchRouter()
+	t := r.gets
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		c := benchContext("GET", "/api/v2/orders/42")
+		for pb.Next() {
+			ps := append([]string{"/"}, t.parsePath("/api/v2/orders/42")...)
+			ms := make(map[string]string)
+			t.mu.Lock()
+			n := t.root.Match(ps, ms)
+			t.mu.Unlock()
+			if n != nil {
+				c.Req.Params = ms
+				n.handler(c)
+			}
+		}
+	})
+}
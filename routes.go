@@ -0,0 +1,188 @@
+package uweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+//
+// Route info for listing
+//
+type RouteInfo struct {
+	Host    string `json:"host,omitempty"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Name    string `json:"name,omitempty"`
+	Handler string `json:"handler"`
+	Source  string `json:"source"`
+}
+
+// All routes of host routers and default one
+func (h *HostRouter) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for _, hr := range h.routes() {
+		for _, rt := range hr.r.Routes() {
+			rt.Host = hr.pattern
+			routes = append(routes, rt)
+		}
+	}
+	if h.def != nil {
+		routes = append(routes, h.def.Routes()...)
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// All routes of router, sorted by path and method
+func (r *Router) Routes() []RouteInfo {
+	// names by method and path
+	r.mu.RLock()
+	names := make(map[string]string, len(r.names))
+	for name, rt := range r.names {
+		names[rt.method+" "+rt.path] = name
+	}
+	r.mu.RUnlock()
+
+	// leaves of trees
+	var routes []RouteInfo
+	for _, m := range r.methods() {
+		t := r.treeByMethod(m)
+		t.mu.Lock()
+		t.root.walk(func(n *RNode) {
+			routes = append(routes, RouteInfo{
+				Method:  m,
+				Path:    n.path,
+				Name:    names[m+" "+n.path],
+				Handler: n.handlerName(),
+				Source:  n.source,
+			})
+		})
+		t.mu.Unlock()
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// Call f with each node has handler
+func (n *RNode) walk(f func(n *RNode)) {
+	if n.handler != nil {
+		f(n)
+	}
+	for _, c := range n.child {
+		c.walk(f)
+	}
+}
+
+// All routes of routers used by app, including mounted
+// sub applications with prefix
+func (a *Application) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for _, m := range a.mws {
+		switch v := m.(type) {
+		case *Router:
+			routes = append(routes, v.Routes()...)
+		case *HostRouter:
+			routes = append(routes, v.Routes()...)
+		case *mount:
+			for _, rt := range v.app.Routes() {
+				rt.Path = v.prefix + rt.Path
+				routes = append(routes, rt)
+			}
+		}
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// Print routes as table, with HOST column if any host
+// router used, such as:
+//
+//	METHOD  PATH        NAME       HANDLER         SOURCE
+//	GET     /users/:id  user.show  main.showUser   main.go:20
+//
+func (a *Application) PrintRoutes(w io.Writer) error {
+	routes := a.Routes()
+	hosts := false
+	for _, rt := range routes {
+		hosts = hosts || len(rt.Host) > 0
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if hosts {
+		fmt.Fprint(tw, "HOST\t")
+	}
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tHANDLER\tSOURCE")
+	for _, rt := range routes {
+		if hosts {
+			fmt.Fprintf(tw, "%s\t", rt.Host)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", rt.Method, rt.Path, rt.Name, rt.Handler, rt.Source)
+	}
+	return tw.Flush()
+}
+
+// Print routes as json array
+func (a *Application) PrintRoutesJson(w io.Writer) error {
+	routes := a.Routes()
+	if routes == nil {
+		routes = []RouteInfo{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(routes)
+}
+
+// sort by host, path, then method in Allow header order,
+// other methods are after standard ones
+func sortRoutes(routes []RouteInfo) {
+	order := make(map[string]int, len(routeMethods))
+	for i, m := range routeMethods {
+		order[m] = i + 1
+	}
+	rank := func(m string) int {
+		if i, ok := order[m]; ok {
+			return i
+		}
+		return len(routeMethods) + 1
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if rank(a.Method) != rank(b.Method) {
+			return rank(a.Method) < rank(b.Method)
+		}
+		return a.Method < b.Method
+	})
+}
+
+// Name of handler for listing
+func (n *RNode) handlerName() string {
+	if len(n.hname) > 0 {
+		return n.hname
+	}
+	return funcName(n.handler)
+}
+
+// Function name of handler, such as "main.showUser"
+func funcName(h interface{}) string {
+	v := reflect.ValueOf(h)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	return strings.TrimSuffix(f.Name(), "-fm")
+}
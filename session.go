@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sort"
 )
 
 var (
@@ -46,8 +47,9 @@ func (m *SessMan) Name() string {
 // @impl Middleware
 func (m *SessMan) Handle(c *Context) int {
 	// read sid from cookie
+	o := c.Options()
 	sid, newSess := "", true
-	if k, err := c.Req.Cookie(SID_COOKIE_KEY); err == nil && k != nil {
+	if k, err := c.Req.Cookie(o.SidCookieKey); err == nil && k != nil {
 		sid = k.Value
 	}
 	if len(sid) > 0 {
@@ -58,16 +60,16 @@ func (m *SessMan) Handle(c *Context) int {
 	s := NewSession(sid)
 	if newSess {
 		http.SetCookie(c.Res, &http.Cookie{
-			Name:     SID_COOKIE_KEY,
+			Name:     o.SidCookieKey,
 			Value:    s.Id(),
-			Domain:   SID_COOKIE_DOMAIN,
+			Domain:   o.SidCookieDomain,
 			Path:     "/",
 			HttpOnly: true,
 			MaxAge:   365 * 24 * 3600,
 		})
 	} else {
 		if err := s.restore(c.Cache); err != nil {
-			log.Println(LOG_TAG, "Session: restore err", err)
+			log.Println(o.LogTag, "Session: restore err", err)
 			// if memcache not start, and sid exist in cookie,
 			// make it as new session
 			if err != ErrCacheMiss {
@@ -84,7 +86,7 @@ func (m *SessMan) Handle(c *Context) int {
 
 	// save session
 	if err := s.save(c.Cache, m.expire); err != nil {
-		log.Println(LOG_TAG, "Session: save err", err)
+		log.Println(o.LogTag, "Session: save err", err)
 		c.Res.Status = 500
 		c.Res.Err = err
 		return NEXT_BREAK
@@ -151,6 +153,16 @@ func (s *Session) Has(k string) bool {
 	return ok
 }
 
+// Get all keys in order
+func (s *Session) Keys() []string {
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Del item
 func (s *Session) Del(k string) {
 	if _, ok := s.data[k]; ok {
@@ -0,0 +1,189 @@
+package uweb
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrAppClosed = errors.New("Application: closed")
+)
+
+// Register hook to run after all servers stopped,
+// hooks run in reverse order of registration
+func (a *Application) OnShutdown(f func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hooks = append(a.hooks, f)
+}
+
+// Shutdown stop accepting new connections, wait active
+// requests until ctx done, and then run shutdown hooks.
+// It is safe to call more than once.
+func (a *Application) Shutdown(ctx context.Context) error {
+	var err error
+	first := false
+	a.downOnce.Do(func() {
+		first = true
+		err = a.shutdown(ctx)
+		close(a.done)
+	})
+	if first {
+		return err
+	}
+
+	// wait the first caller
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do the real shutdown
+func (a *Application) shutdown(ctx context.Context) error {
+	// refuse new servers
+	a.mu.Lock()
+	a.closing = true
+	srvs := a.srvs
+	a.srvs = nil
+	a.mu.Unlock()
+	unregister(a)
+	if a.opts.Debug {
+		log.Println(a.opts.LogTag, "Application: shutdown", len(srvs), "servers")
+	}
+
+	// stop listeners and wait idle connections
+	var err error
+	for _, s := range srvs {
+		if e := s.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	// wait requests server not tracked, such as hijacked
+	if e := a.wait(ctx); e != nil && err == nil {
+		err = e
+	}
+
+	// hooks, even if timeout, as they may save states
+	a.mu.Lock()
+	hooks := a.hooks
+	a.mu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+
+	// ok
+	return err
+}
+
+// wait all active requests finished
+func (a *Application) wait(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if atomic.LoadInt64(&a.active) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Serve srv on ln until shutdown finished
+func (a *Application) serve(srv *http.Server, ln net.Listener) error {
+	// register
+	a.mu.Lock()
+	if a.closing {
+		a.mu.Unlock()
+		ln.Close()
+		return ErrAppClosed
+	}
+	a.srvs = append(a.srvs, srv)
+	a.mu.Unlock()
+
+	// signals, watched once for all apps
+	register(a)
+	sigOnce.Do(watchSignals)
+
+	// serve, if closed by Shutdown, wait it finish
+	var err error
+	if srv.TLSConfig != nil {
+		err = srv.ServeTLS(ln, "", "") // certs in TLSConfig
+	} else {
+		err = srv.Serve(ln)
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	<-a.done
+	return nil
+}
+
+// Shutdown all apps gracefully on SIGINT or SIGTERM, a
+// second signal will kill the process as default. Restart
+// on SIGUSR2 if supported.
+func watchSignals() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	if restartSignal != nil {
+		signal.Notify(ch, restartSignal)
+	}
+	go func() {
+		for sig := range ch {
+			log.Println(LOG_TAG, "Application: got signal", sig)
+
+			// start child first, keep serving if fail
+			if sig == restartSignal {
+				if err := fork(); err != nil {
+					log.Println(LOG_TAG, "Application: restart err", err)
+					continue
+				}
+			}
+			signal.Stop(ch)
+
+			shutdownAll()
+			return
+		}
+	}()
+}
+
+// Shutdown all registered apps at the same time, each
+// waits by its own ShutdownTimeout
+func shutdownAll() error {
+	apps := registered()
+	errs := make([]error, len(apps))
+	var wg sync.WaitGroup
+	for i, a := range apps {
+		wg.Add(1)
+		go func(i int, a *Application) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), a.opts.ShutdownTimeout)
+			defer cancel()
+			if errs[i] = a.Shutdown(ctx); errs[i] != nil {
+				log.Println(a.opts.LogTag, "Application: shutdown err", errs[i])
+			}
+		}(i, a)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
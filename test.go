@@ -13,107 +13,114 @@ import (
 // We may run several tests, but only setup once
 //
 var (
-	testOnce sync.Once
-	testApp  *Application
+	testOnce   sync.Once
+	testApp    *Application
+	testTester *Tester
 )
 
 func setupTest() {
 	testOnce.Do(func() {
 		testApp = NewApp()
 		testApp.Use(MdRouter())
+		testTester = NewTester(testApp)
 	})
 }
 
 //
-// Test Get handler
+// Test Get handler of default router
 //
 func TestGet(path, query string) (*httptest.ResponseRecorder, error) {
-	// init
 	setupTest()
-
-	// req
-	req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost%s?%s", path, query), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// w
-	w := httptest.NewRecorder()
-
-	// handle
-	testApp.ServeHTTP(w, req)
-
-	// ok
-	return w, nil
+	return testTester.Get(path, query)
 }
 
 //
-// Test Post Handler
+// Test Post Handler of default router
 //
 func TestPost(path string, data url.Values) (*httptest.ResponseRecorder, error) {
-	// init
 	setupTest()
+	return testTester.Post(path, data)
+}
 
-	// req
-	req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost%s", path), strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// w
-	w := httptest.NewRecorder()
+//
+// Test Put Handler of default router
+//
+func TestPut(path string, data url.Values) (*httptest.ResponseRecorder, error) {
+	setupTest()
+	return testTester.Put(path, data)
+}
 
-	// handle
-	testApp.ServeHTTP(w, req)
+//
+// Test Del Handler of default router
+//
+func TestDel(path string) (*httptest.ResponseRecorder, error) {
+	setupTest()
+	return testTester.Del(path)
+}
 
-	// ok
-	return w, nil
+//
+// Create tester of app, such as:
+//
+//	app := uweb.NewApp()
+//	app.Use(router)
+//	w, err := uweb.NewTester(app).Get("/users/42", "")
+//
+func NewTester(app *Application) *Tester {
+	return &Tester{
+		app:  app,
+		Host: "localhost",
+	}
 }
 
 //
-// Test Put Handler
+// Tester sends requests to application without listening
 //
-func TestPut(path string, data url.Values) (*httptest.ResponseRecorder, error) {
-	// init
-	setupTest()
+type Tester struct {
+	app  *Application
+	Host string // host of request, for host routers
+}
 
-	// req
-	req, err := http.NewRequest("PUT", fmt.Sprintf("http://localhost%s", path), strings.NewReader(data.Encode()))
+// Test Get handler
+func (t *Tester) Get(path, query string) (*httptest.ResponseRecorder, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://%s%s?%s", t.Host, path, query), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// w
-	w := httptest.NewRecorder()
+	return t.Do(req), nil
+}
 
-	// handle
-	testApp.ServeHTTP(w, req)
+// Test Post Handler
+func (t *Tester) Post(path string, data url.Values) (*httptest.ResponseRecorder, error) {
+	return t.form("POST", path, data)
+}
 
-	// ok
-	return w, nil
+// Test Put Handler
+func (t *Tester) Put(path string, data url.Values) (*httptest.ResponseRecorder, error) {
+	return t.form("PUT", path, data)
 }
 
-//
 // Test Del Handler
-//
-func TestDel(path string) (*httptest.ResponseRecorder, error) {
-	// init
-	setupTest()
+func (t *Tester) Del(path string) (*httptest.ResponseRecorder, error) {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("http://%s%s", t.Host, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	return t.Do(req), nil
+}
 
-	// req
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("http://localhost%s", path), nil)
+// Send request with form data
+func (t *Tester) form(method, path string, data url.Values) (*httptest.ResponseRecorder, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", t.Host, path), strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return t.Do(req), nil
+}
 
-	// w
+// Send any request, such as with custom method or headers
+func (t *Tester) Do(req *http.Request) *httptest.ResponseRecorder {
 	w := httptest.NewRecorder()
-
-	// handle
-	testApp.ServeHTTP(w, req)
-
-	// ok
-	return w, nil
+	t.app.ServeHTTP(w, req)
+	return w
 }
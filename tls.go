@@ -0,0 +1,265 @@
+package uweb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// Check cert files changes at most once in this interval
+	CERT_RELOAD_INTERVAL = 10 * time.Second
+)
+
+var (
+	ErrNoCert = errors.New("TLS: no certificate")
+)
+
+// Add cert pair, the right one will be selected by SNI.
+// The first added is the default one.
+func (a *Application) AddCert(certFile, keyFile string) error {
+	return a.certs.add(certFile, keyFile, false)
+}
+
+// Listen plain http at addr and redirect all requests to
+// https permanently, need call before ListenTLS
+func (a *Application) RedirectHTTP(addr string) {
+	a.redirect = addr
+}
+
+// Listen and serve https, cert files will be reloaded
+// if changed, so no need restart when renew certs
+func (a *Application) ListenTLS(addr, certFile, keyFile string) error {
+	// certs, this pair is the default
+	if err := a.certs.add(certFile, keyFile, true); err != nil {
+		return err
+	}
+
+	// ln
+	ln, err := a.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if a.opts.Debug {
+		log.Println(a.opts.LogTag, "Application: listen tls at", addr)
+	}
+
+	// redirect, listen here to return the error
+	if len(a.redirect) > 0 {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			a.drop(ln)
+			return err
+		}
+		rln, err := a.listen("tcp", a.redirect)
+		if err != nil {
+			a.drop(ln)
+			return err
+		}
+		go func() {
+			if err := a.serveRedirect(rln, port); err != nil {
+				log.Println(a.opts.LogTag, "Application: redirect err", err)
+			}
+		}()
+	}
+
+	// serve
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: a,
+		TLSConfig: &tls.Config{
+			GetCertificate: a.certs.GetCertificate,
+		},
+	}
+	return a.serve(srv, ln)
+}
+
+// Serve a redirect app on ln, which shares the shutdown
+// of a
+func (a *Application) serveRedirect(ln net.Listener, port string) error {
+	if a.opts.Debug {
+		log.Println(a.opts.LogTag, "Application: redirect http at", a.redirect)
+	}
+
+	r := NewApp(a.opts)
+	r.Use(MdRedirect())
+	r.Use(&tlsRedirect{port: port})
+	return a.serve(&http.Server{Addr: a.redirect, Handler: r}, ln)
+}
+
+//
+// Redirect to https with same host and uri
+//
+type tlsRedirect struct {
+	port string // https port
+}
+
+func (t *tlsRedirect) Name() string {
+	return "tlsredirect"
+}
+
+// @impl Middleware
+func (t *tlsRedirect) Handle(c *Context) int {
+	// host without port, ipv6 without brackets
+	host := c.Req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if len(host) == 0 {
+		c.Res.Plain(400, "missing host")
+		return NEXT_BREAK
+	}
+
+	// bracket ipv6 again, and omit default port
+	port := t.port
+	if len(port) == 0 {
+		port = "443"
+	}
+	host = strings.TrimSuffix(net.JoinHostPort(host, port), ":443")
+	c.Redirect.Permanent("https://" + host + c.Req.URL.RequestURI())
+	return NEXT_BREAK
+}
+
+//
+// Cert pair which reload from disk if changed
+//
+type certPair struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	checked time.Time // last check time
+	modTime time.Time // files mod time of current cert
+	cert    *tls.Certificate
+}
+
+// Load cert pair
+func newCertPair(certFile, keyFile string) (*certPair, error) {
+	p := &certPair{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// latest mod time of cert and key files
+func (p *certPair) stat() (time.Time, error) {
+	var t time.Time
+	for _, f := range []string{p.certFile, p.keyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return t, err
+		}
+		if info.ModTime().After(t) {
+			t = info.ModTime()
+		}
+	}
+	return t, nil
+}
+
+// read files
+func (p *certPair) load() error {
+	modTime, err := p.stat()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+	p.cert = &cert
+	p.modTime = modTime
+	p.checked = time.Now()
+	return nil
+}
+
+// Get cert, reload if files changed. If reload fail,
+// keep the old one, as files may be in writing.
+func (p *certPair) get(opts *Options) *tls.Certificate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.checked) < CERT_RELOAD_INTERVAL {
+		return p.cert
+	}
+	p.checked = time.Now()
+
+	if modTime, err := p.stat(); err == nil && modTime.After(p.modTime) {
+		if err := p.load(); err != nil {
+			log.Println(opts.LogTag, "TLS: reload cert err", p.certFile, err)
+		} else if opts.Debug {
+			log.Println(opts.LogTag, "TLS: reload cert", p.certFile)
+		}
+	}
+	return p.cert
+}
+
+//
+// Cert pairs selected by SNI
+//
+type certStore struct {
+	opts  *Options // of app, for logging
+	mu    sync.RWMutex
+	pairs []*certPair
+}
+
+// Add cert pair, ignore if added
+func (s *certStore) add(certFile, keyFile string, def bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.pairs {
+		if p.certFile == certFile && p.keyFile == keyFile {
+			if def {
+				s.pairs[0], s.pairs[i] = s.pairs[i], s.pairs[0]
+			}
+			return nil
+		}
+	}
+
+	p, err := newCertPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	if def {
+		s.pairs = append([]*certPair{p}, s.pairs...)
+	} else {
+		s.pairs = append(s.pairs, p)
+	}
+	return nil
+}
+
+// Select cert by server name, fallback to the default
+// @impl tls.Config.GetCertificate
+func (s *certStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	pairs := s.pairs
+	s.mu.RUnlock()
+
+	if len(pairs) == 0 {
+		return nil, ErrNoCert
+	}
+	for _, p := range pairs {
+		cert := p.get(s.opts)
+		if hello.SupportsCertificate(cert) == nil {
+			return cert, nil
+		}
+	}
+	return pairs[0].get(s.opts), nil
+}
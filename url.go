@@ -0,0 +1,200 @@
+package uweb
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrRouteName = errors.New("Router: route name not found")
+)
+
+// Routers having named routes, in order of first named
+var (
+	namedMu      sync.Mutex
+	namedRouters []*Router
+)
+
+// Register url helper for templates, such as
+// {{url "user.show" "id" .Id}}, apps may replace it
+// by Helper("url", ...). Render resolves it by app.URL of
+// the request's app with mount prefix, and this one is
+// for Template.Execute only.
+func init() {
+	builtinHelper("url", URL)
+}
+
+// Generate url by named route of default router, then
+// other routers in order of first named. Use app.URL for
+// routers of mounted applications, which knows prefix.
+func URL(name string, pairs ...interface{}) (string, error) {
+	u, err := defaultRouter.URL(name, pairs...)
+	if err != ErrRouteName {
+		return u, err
+	}
+
+	namedMu.Lock()
+	routers := namedRouters
+	namedMu.Unlock()
+
+	for _, r := range routers {
+		if r == defaultRouter {
+			continue
+		}
+		if u, err := r.URL(name, pairs...); err != ErrRouteName {
+			return u, err
+		}
+	}
+	return "", ErrRouteName
+}
+
+// Generate url by named route of routers used by app,
+// including those of host routers and mounted apps
+func (a *Application) URL(name string, pairs ...interface{}) (string, error) {
+	for _, m := range a.mws {
+		var u string
+		var err error
+		switch v := m.(type) {
+		case *Router:
+			u, err = v.URL(name, pairs...)
+		case *HostRouter:
+			u, err = v.URL(name, pairs...)
+		case *mount:
+			if u, err = v.app.URL(name, pairs...); err == nil {
+				u = v.prefix + u
+			}
+		default:
+			continue
+		}
+		if err != ErrRouteName {
+			return u, err
+		}
+	}
+	return "", ErrRouteName
+}
+
+// Generate url by named route of host routers, then the
+// default one
+func (h *HostRouter) URL(name string, pairs ...interface{}) (string, error) {
+	for _, hr := range h.routes() {
+		if u, err := hr.r.URL(name, pairs...); err != ErrRouteName {
+			return u, err
+		}
+	}
+	if h.def != nil {
+		return h.def.URL(name, pairs...)
+	}
+	return "", ErrRouteName
+}
+
+//
+// Route added to router, can be named for url generation
+//
+type Route struct {
+	r      *Router
+	method string
+	path   string
+	name   string
+}
+
+// Name the route, name should be unique in router
+func (rt *Route) Name(name string) *Route {
+	rt.r.mu.Lock()
+	defer rt.r.mu.Unlock()
+
+	if _, ok := rt.r.names[name]; ok {
+		panic("Router: DUP route name " + name)
+	}
+	rt.name = name
+	rt.r.names[name] = rt
+
+	// first named, for url helper
+	if len(rt.r.names) == 1 {
+		namedMu.Lock()
+		namedRouters = append(namedRouters, rt.r)
+		namedMu.Unlock()
+	}
+	return rt
+}
+
+// Generate url of named route, pairs are param name and
+// value, such as URL("user.show", "id", 42). Params not in
+// pattern are added to query.
+func (r *Router) URL(name string, pairs ...interface{}) (string, error) {
+	// route
+	r.mu.RLock()
+	rt, ok := r.names[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", ErrRouteName
+	}
+
+	// params
+	if len(pairs)%2 != 0 {
+		return "", errors.New("Router: odd params of " + name)
+	}
+	params := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		params[fmt.Sprint(pairs[i])] = fmt.Sprint(pairs[i+1])
+	}
+
+	// fill path
+	u, err := fillPath(name, rt.path, params)
+	if err != nil {
+		return "", err
+	}
+
+	// others to query
+	if len(params) > 0 {
+		q := make(url.Values, len(params))
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		u += "?" + q.Encode()
+	}
+	return u, nil
+}
+
+// Fill params to pattern with escaping, used params are
+// deleted, and name is for errors
+func fillPath(name, pattern string, params map[string]string) (string, error) {
+	segs := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, seg := range segs {
+		if len(seg) == 0 {
+			continue
+		}
+		n, err := newRNode(seg)
+		if err != nil {
+			return "", err
+		}
+		if !n.isParam() && !n.isWild() {
+			continue
+		}
+		v, ok := params[n.key()]
+		if !ok {
+			return "", errors.New("Router: missing param " + n.key() + " of " + name)
+		}
+		delete(params, n.key())
+		if n.isWild() {
+			// keep slashes
+			parts := strings.Split(v, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segs[i] = strings.Join(parts, "/")
+			continue
+		}
+		if n.re != nil && !n.re.MatchString(v) {
+			return "", errors.New("Router: invalid param " + n.key() + " of " + name)
+		}
+		segs[i] = url.PathEscape(v)
+	}
+	u := "/" + strings.Join(segs, "/")
+	if len(u) > 1 && strings.HasSuffix(pattern, "/") {
+		u += "/"
+	}
+	return u, nil
+}
@@ -0,0 +1,357 @@
+package uweb
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	ErrValidateTarget = errors.New("Validate: target should be struct or pointer to struct")
+)
+
+//
+// Validate rule, v is field value with pointer resolved,
+// and param is after "=", such as "3" of "min=3"
+//
+type Rule func(v reflect.Value, param string) bool
+
+// rule, its default message, and check of param when
+// tag parsed, nil if any param is ok
+type ruleEntry struct {
+	rule  Rule
+	msg   string
+	check func(param string) error
+}
+
+// Rules by name
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]ruleEntry{
+		"required": {ruleRequired, "required", nil},
+		"min":      {ruleMin, "at least {param}", checkNumber},
+		"max":      {ruleMax, "at most {param}", checkNumber},
+		"len":      {ruleLen, "length should be {param}", checkNumber},
+		"oneof":    {ruleOneOf, "should be one of {param}", nil},
+		"email":    {ruleRegexp(`^[^@\s]+@[^@\s]+\.[^@\s]+$`), "invalid email", nil},
+		"url":      {ruleURL, "invalid url", nil},
+		"alpha":    {ruleRegexp(`^[a-zA-Z]*$`), "should be letters", nil},
+		"alnum":    {ruleRegexp(`^[a-zA-Z0-9]*$`), "should be letters or digits", nil},
+		"numeric":  {ruleRegexp(`^-?[0-9]+(\.[0-9]+)?$`), "should be number", nil},
+	}
+)
+
+// Register validate rule, msg is default message, and
+// "{field}" and "{param}" in it are replaced, such as:
+//
+//	uweb.ValidateRule("mobile", "invalid mobile", func(v reflect.Value, param string) bool {
+//		return mobileRe.MatchString(v.String())
+//	})
+//
+func ValidateRule(name, msg string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	if _, ok := rules[name]; ok {
+		panic("Validate: DUP rule " + name)
+	}
+	rules[name] = ruleEntry{rule, msg, nil}
+}
+
+// Validate struct by "validate" tag, such as
+// `validate:"required,min=3"`. If not required, rules are
+// skipped for empty string, slice, map and nil pointer,
+// and for zero numbers too with "omitempty". Nested
+// structs are validated too. Errors are FieldErrors
+// named as Bind.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrValidateTarget
+	}
+	var errs FieldErrors
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	return errs.orNil()
+}
+
+// Validate fields of struct
+func validateStruct(v reflect.Value, prefix string, errs *FieldErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if len(sf.PkgPath) > 0 && !(sf.Anonymous && fv.Kind() == reflect.Struct) {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+		key := prefix + name
+		if sf.Anonymous && len(sf.Tag.Get("form")) == 0 && fv.Kind() == reflect.Struct {
+			key = strings.TrimSuffix(prefix, ".")
+		}
+
+		// rules of field
+		if tag := sf.Tag.Get("validate"); len(tag) > 0 {
+			ok, err := validateField(fv, key, tag, errs)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		// nested
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && !isScalar(fv.Type()) {
+			p := key + "."
+			if len(key) == 0 {
+				p = ""
+			}
+			if err := validateStruct(fv, p, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate field by rules, stop at first failed, and
+// returns false if failed
+func validateField(v reflect.Value, key, tag string, errs *FieldErrors) (bool, error) {
+	pt, err := parseTag(tag)
+	if err != nil {
+		return false, errors.New(err.Error() + " of " + key)
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !pt.required && (isAbsent(v) || (pt.omit && isEmpty(v))) {
+		return true, nil
+	}
+
+	for _, r := range pt.rules {
+		if r.entry.rule(v, r.param) {
+			continue
+		}
+		*errs = append(*errs, &FieldError{
+			Field: key,
+			Value: valueString(v),
+			Rule:  r.name,
+			Param: r.param,
+			Err:   errors.New(strings.NewReplacer("{field}", key, "{param}", r.param).Replace(r.entry.msg)),
+		})
+		return false, nil
+	}
+	return true, nil
+}
+
+// rule in tag with its param
+type tagRule struct {
+	name  string
+	param string
+	entry ruleEntry
+}
+
+// parsed "validate" tag
+type parsedTag struct {
+	rules    []tagRule
+	required bool
+	omit     bool // omitempty
+}
+
+// Parsed tags, by tag
+var tagCache sync.Map
+
+// Parse tag and check rules and params, cached if ok
+func parseTag(tag string) (*parsedTag, error) {
+	if pt, ok := tagCache.Load(tag); ok {
+		return pt.(*parsedTag), nil
+	}
+
+	pt := &parsedTag{}
+	for _, r := range strings.Split(tag, ",") {
+		name, param := r, ""
+		if i := strings.IndexByte(r, '='); i >= 0 {
+			name, param = r[:i], r[i+1:]
+		}
+		switch name {
+		case "required":
+			pt.required = true
+		case "omitempty":
+			pt.omit = true
+			continue
+		}
+		rulesMu.RLock()
+		entry, ok := rules[name]
+		rulesMu.RUnlock()
+		if !ok {
+			return nil, errors.New("Validate: unknown rule " + name)
+		}
+		if entry.check != nil {
+			if err := entry.check(param); err != nil {
+				return nil, errors.New("Validate: invalid param " + r)
+			}
+		}
+		pt.rules = append(pt.rules, tagRule{name, param, entry})
+	}
+	tagCache.Store(tag, pt)
+	return pt, nil
+}
+
+// Messages of errors by field, translated by locale if
+// "validate" section has rule name as key, and field is
+// labeled by "fields" section, such as:
+//
+//	[validate]
+//	required = {field} is required
+//	[fields]
+//	name = Name
+//
+func (es FieldErrors) Messages(l *Locale) map[string]string {
+	msgs := make(map[string]string, len(es))
+	for _, e := range es {
+		msgs[e.Field] = e.Message(l)
+	}
+	return msgs
+}
+
+// Message translated by locale, l may be nil
+func (e *FieldError) Message(l *Locale) string {
+	if l == nil || len(e.Rule) == 0 {
+		return e.Err.Error()
+	}
+	msg, ok := l.Lookup("validate", e.Rule)
+	if !ok {
+		return e.Err.Error()
+	}
+	label, ok := l.Lookup("fields", e.Field)
+	if !ok {
+		label = e.Field
+	}
+	return strings.NewReplacer("{field}", label, "{param}", e.Param).Replace(msg)
+}
+
+// Not given, empty string, slice and map, or nil, but
+// zero number is a value
+func isAbsent(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Zero, or empty string, slice and map
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// Value for error, empty if not scalar
+func valueString(v reflect.Value) string {
+	if !v.IsValid() || !v.CanInterface() || !isScalar(v.Type()) {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func ruleRequired(v reflect.Value, param string) bool {
+	return !isEmpty(v)
+}
+
+// Size is length of string in runes, length of slice and
+// map, or number itself
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// param should be number
+func checkNumber(param string) error {
+	_, err := strconv.ParseFloat(param, 64)
+	return err
+}
+
+// compare size with param, which is checked by checkNumber
+func ruleCompare(cmp func(a, b float64) bool) Rule {
+	return func(v reflect.Value, param string) bool {
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		s, ok := size(v)
+		return ok && cmp(s, n)
+	}
+}
+
+var (
+	ruleMin = ruleCompare(func(a, b float64) bool { return a >= b })
+	ruleMax = ruleCompare(func(a, b float64) bool { return a <= b })
+	ruleLen = ruleCompare(func(a, b float64) bool { return a == b })
+)
+
+// "oneof=a b c"
+func ruleOneOf(v reflect.Value, param string) bool {
+	s := valueString(v)
+	for _, p := range strings.Fields(param) {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// string matches expr
+func ruleRegexp(expr string) Rule {
+	re := regexp.MustCompile(expr)
+	return func(v reflect.Value, param string) bool {
+		return v.Kind() == reflect.String && re.MatchString(v.String())
+	}
+}
+
+// absolute url
+func ruleURL(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	u, err := url.Parse(v.String())
+	return err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+}